@@ -0,0 +1,103 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"sort"
+)
+
+// Applied records the variables set in the environment of the current process,
+// with their previous values, so that setting them can be undone.
+type Applied struct {
+	keys []string
+	// previous holds the value of each key before it was first set, nil if it was not set
+	previous map[string]*string
+}
+
+// LoadApplied works like Load, additionally returning what was set in the environment.
+// The record is returned even with an error, as files read before the error are loaded.
+func LoadApplied(filenames ...string) (*Applied, error) {
+	return NewLoader().LoadApplied(filenames...)
+}
+
+// OverloadApplied works like Overload, additionally returning what was set in the environment.
+// The record is returned even with an error, as files read before the error are loaded.
+func OverloadApplied(filenames ...string) (*Applied, error) {
+	return NewLoader().OverloadApplied(filenames...)
+}
+
+// LoadApplied works like the package-level LoadApplied.
+func (l *Loader) LoadApplied(filenames ...string) (*Applied, error) {
+	applied := &Applied{}
+	_, err := l.loadFiles(filenames, false, applied)
+	return applied, err
+}
+
+// OverloadApplied works like the package-level OverloadApplied.
+func (l *Loader) OverloadApplied(filenames ...string) (*Applied, error) {
+	applied := &Applied{}
+	_, err := l.loadFiles(filenames, true, applied)
+	return applied, err
+}
+
+// Keys returns the sorted keys that were set.
+func (a *Applied) Keys() []string {
+	keys := append([]string(nil), a.keys...)
+	sort.Strings(keys)
+	return keys
+}
+
+// Previous returns the value the key had before it was set, and whether it was set at all.
+func (a *Applied) Previous(key string) (value string, ok bool) {
+	if previous := a.previous[key]; previous != nil {
+		return *previous, true
+	}
+
+	return "", false
+}
+
+// Restore gives back their previous values to the keys that were set,
+// unsetting those that did not exist before.
+// The record is then emptied, so restoring again does nothing.
+func (a *Applied) Restore() error {
+	var errs []error
+	for i := len(a.keys) - 1; i >= 0; i-- {
+		key := a.keys[i]
+		var err error
+		if previous := a.previous[key]; previous != nil {
+			err = os.Setenv(key, *previous)
+		} else {
+			err = os.Unsetenv(key)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	a.keys, a.previous = nil, nil
+	return errors.Join(errs...)
+}
+
+// setenv sets the variable, recording its previous value the first time the key is set.
+// A nil record only sets the variable.
+func (a *Applied) setenv(key, value string) error {
+	if a == nil {
+		return os.Setenv(key, value)
+	}
+
+	if _, ok := a.previous[key]; !ok {
+		if a.previous == nil {
+			a.previous = make(map[string]*string)
+		}
+
+		var previous *string
+		if value, ok := os.LookupEnv(key); ok {
+			previous = &value
+		}
+		a.keys = append(a.keys, key)
+		a.previous[key] = previous
+	}
+
+	return os.Setenv(key, value)
+}
@@ -1,12 +1,27 @@
+// Package autoload loads env files when it is imported
+//
+//	import _ "github.com/pchchv/env/autoload"
+//
+// By default .env is loaded if it exists, without overriding existing variables,
+// and parse errors are reported on the standard error.
+// Relative files are searched for in the current directory and its parents up to the root of the project,
+// like env.Find, and Files lists the paths of the files loaded.
+// Loading is configured by environment variables:
+//
+//	ENV_FILES=a.env,b.env  comma separated list of the files to load instead of .env
+//	ENV_REQUIRED=1         panic if a file is missing or cannot be parsed
+//	ENV_OVERLOAD=1         override existing variables, like env.Overload
+//	ENV_CASCADE=APP_ENV    with APP_ENV=production, load .env.production.local, .env.local,
+//	                       .env.production and .env, in order of precedence (.env.local is skipped for test)
+//
+// The overload and must subpackages always override existing variables and always panic on errors.
 package autoload
 
-/*
-	You can automatically read the .env file when you import it by simply doing the following
-		import _ "github.com/pchchv/env/autoload"
-*/
+import "github.com/pchchv/env/internal/autoenv"
 
-import "github.com/pchchv/env"
+// Files lists the paths of the files loaded.
+var Files []string
 
 func init() {
-	env.Load()
+	Files = autoenv.Run(false, false)
 }
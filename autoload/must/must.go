@@ -0,0 +1,15 @@
+// Package must loads env files when it is imported, panicking if a file is missing or cannot be parsed
+//
+//	import _ "github.com/pchchv/env/autoload/must"
+//
+// It is configured like the autoload package, as if ENV_REQUIRED=1 was set.
+package must
+
+import "github.com/pchchv/env/internal/autoenv"
+
+// Files lists the paths of the files loaded.
+var Files []string
+
+func init() {
+	Files = autoenv.Run(false, true)
+}
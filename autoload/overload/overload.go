@@ -0,0 +1,15 @@
+// Package overload loads env files when it is imported, overriding existing variables
+//
+//	import _ "github.com/pchchv/env/autoload/overload"
+//
+// It is configured like the autoload package, as if ENV_OVERLOAD=1 was set.
+package overload
+
+import "github.com/pchchv/env/internal/autoenv"
+
+// Files lists the paths of the files loaded.
+var Files []string
+
+func init() {
+	Files = autoenv.Run(true, false)
+}
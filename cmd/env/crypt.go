@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/pchchv/env"
+)
+
+// cryptFlags are the flags shared by encrypt, decrypt and rotate-key.
+type cryptFlags struct {
+	filenames []string
+	keyfile   string
+	keys      []string
+}
+
+func parseCryptFlags(name string, args []string) (*cryptFlags, error) {
+	set := flag.NewFlagSet(name, flag.ExitOnError)
+	var envFilenames filenamesFlag
+	set.Var(&envFilenames, "f", "path to a .env file (repeatable, default .env)")
+	keyfile := set.String("k", "", "path to the keyfile (default $"+env.KeyFileEnv+" or "+env.DefaultKeyFile+")")
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if len(envFilenames) == 0 {
+		envFilenames = filenamesFlag{".env"}
+	}
+
+	return &cryptFlags{
+		filenames: envFilenames,
+		keyfile:   *keyfile,
+		keys:      set.Args(),
+	}, nil
+}
+
+// readKey reads the key from the keyfile given with -k,
+// falling back to the key lookup used when loading files.
+func (f *cryptFlags) readKey() ([]byte, error) {
+	if f.keyfile != "" {
+		return env.ReadKeyFile(f.keyfile)
+	}
+
+	return env.ReadKey()
+}
+
+func (f *cryptFlags) keyFilename() string {
+	if f.keyfile != "" {
+		return f.keyfile
+	}
+
+	return env.KeyFilename()
+}
+
+// selected tells whether the key was requested on the command line,
+// all keys are selected if none were given.
+func (f *cryptFlags) selected(key string) bool {
+	if len(f.keys) == 0 {
+		return true
+	}
+
+	for _, k := range f.keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewrittenFile is an env file rewritten in memory.
+type rewrittenFile struct {
+	filename string
+	doc      *env.Document
+	// skipped are the selected keys left as is because their values reference other variables
+	skipped []string
+}
+
+// rewrite applies fn to the selected keys of every file, with their values as written, without decryption
+// or expansion, and returns the files with the values changed by fn, leaving the files on disk untouched.
+// The raw values of the other keys, their comments and order are kept.
+// Values referencing other variables are skipped, or rejected if their key was requested on the command line.
+func (f *cryptFlags) rewrite(fn func(key, value string) (string, error)) ([]rewrittenFile, error) {
+	files := make([]rewrittenFile, 0, len(f.filenames))
+	for _, filename := range f.filenames {
+		doc, err := env.ReadDocument(filename)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		file := rewrittenFile{filename: filename, doc: doc}
+		for _, key := range doc.Keys() {
+			if !f.selected(key) {
+				continue
+			}
+
+			literal, ok := doc.Literal(key)
+			if !ok && len(f.keys) > 0 {
+				return nil, fmt.Errorf("%s: the value of %s references other variables", filename, key)
+			} else if !ok {
+				file.skipped = append(file.skipped, key)
+				continue
+			}
+
+			value, err := fn(key, literal)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+
+			if value != literal {
+				doc.Set(key, value)
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// writeFiles writes the rewritten files, each replaced at once.
+// It stops at the first failure, leaving the files that are not written yet unchanged.
+func writeFiles(files []rewrittenFile) error {
+	for i, file := range files {
+		if err := file.doc.Write(file.filename); err != nil {
+			return fmt.Errorf("%s: %w (%d of %d files written)", file.filename, err, i, len(files))
+		}
+	}
+
+	return nil
+}
+
+// encrypt encrypts values in env files, creating the keyfile if there is no key yet.
+func encrypt(args []string) error {
+	flags, err := parseCryptFlags("encrypt", args)
+	if err != nil {
+		return err
+	}
+
+	key, err := flags.readKey()
+	generated := errors.Is(err, fs.ErrNotExist)
+	if generated {
+		key, err = env.GenerateKey()
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := flags.rewrite(func(name, value string) (string, error) {
+		if env.IsEncrypted(value) {
+			return value, nil
+		}
+
+		return env.Encrypt(key, name, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		for _, name := range file.skipped {
+			fmt.Fprintf(os.Stderr, "%s: %s is not encrypted, its value references other variables\n", file.filename, name)
+		}
+	}
+
+	if generated {
+		if err = env.WriteKeyFile(key, flags.keyFilename()); err != nil {
+			return err
+		}
+	}
+
+	return writeFiles(files)
+}
+
+// decrypt replaces encrypted values in env files with their plain text.
+func decrypt(args []string) error {
+	flags, err := parseCryptFlags("decrypt", args)
+	if err != nil {
+		return err
+	}
+
+	key, err := flags.readKey()
+	if err != nil {
+		return err
+	}
+
+	files, err := flags.rewrite(recrypter(key, nil))
+	if err != nil {
+		return err
+	}
+
+	return writeFiles(files)
+}
+
+// rotateKey re-encrypts all encrypted values with a new key and replaces the keyfile.
+// Every file is re-encrypted in memory, the new key is saved next to the keyfile,
+// the files are replaced one by one and only then the new key is renamed over the keyfile,
+// so that a failure never leaves values encrypted with a key that was not saved.
+func rotateKey(args []string) error {
+	flags, err := parseCryptFlags("rotate-key", args)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := os.LookupEnv(env.KeyEnv); ok && flags.keyfile == "" {
+		return fmt.Errorf("the key is read from $%s, which cannot be replaced: unset it or give the keyfile with -k", env.KeyEnv)
+	}
+
+	keyfile := flags.keyFilename()
+	newKeyfile := keyfile + ".new"
+	if _, err = os.Stat(newKeyfile); err == nil {
+		return fmt.Errorf("%s exists, a previous rotation did not complete: files may be encrypted with the key it holds", newKeyfile)
+	}
+
+	oldKey, err := flags.readKey()
+	if err != nil {
+		return err
+	}
+
+	newKey, err := env.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	files, err := flags.rewrite(recrypter(oldKey, newKey))
+	if err != nil {
+		return err
+	}
+
+	if err = env.WriteKeyFile(newKey, newKeyfile); err != nil {
+		return err
+	}
+
+	if err = writeFiles(files); err != nil {
+		return fmt.Errorf("%w: the old key is kept in %s and the new key in %s", err, keyfile, newKeyfile)
+	}
+
+	return os.Rename(newKeyfile, keyfile)
+}
+
+// recrypter returns a function decrypting encrypted values with oldKey
+// and, if newKey is not nil, encrypting them again with newKey.
+func recrypter(oldKey, newKey []byte) func(name, value string) (string, error) {
+	return func(name, value string) (string, error) {
+		if !env.IsEncrypted(value) {
+			return value, nil
+		}
+
+		value, err := env.Decrypt(oldKey, name, value)
+		if err != nil || newKey == nil {
+			return value, err
+		}
+
+		return env.Encrypt(newKey, name, value)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pchchv/env"
+)
+
+// diff compares two env files, or an env file with the environment,
+// and exits with status 1 if they differ.
+func diff(args []string) error {
+	set := flag.NewFlagSet("diff", flag.ExitOnError)
+	live := set.Bool("live", false, "compare the file with the current environment")
+	asJSON := set.Bool("json", false, "print the differences as JSON")
+	reveal := set.Bool("reveal", false, "show the values of sensitive keys")
+	filenames, err := parseInterspersed(set, args)
+	if err != nil {
+		return err
+	}
+
+	if (*live && len(filenames) != 1) || (!*live && len(filenames) != 2) {
+		return errors.New("usage: env diff [-json] [-reveal] OLD_ENV_FILE (NEW_ENV_FILE | -live)")
+	}
+
+	oldMap, oldProv, err := env.ReadWithProvenance(filenames[0])
+	if err != nil {
+		return err
+	}
+
+	newMap, newProv := make(map[string]string), make(env.Provenance)
+	if *live {
+		// only the keys of the file are compared, the environment has many others
+		for key := range oldMap {
+			if value, ok := os.LookupEnv(key); ok {
+				newMap[key] = value
+			}
+		}
+	} else if newMap, newProv, err = env.ReadWithProvenance(filenames[1]); err != nil {
+		return err
+	}
+
+	changes := env.Diff(oldMap, newMap)
+	if !*reveal {
+		for i, c := range changes {
+			if oldProv.Sensitive(c.Key) || newProv.Sensitive(c.Key) {
+				changes[i].Old = redact(c.Old)
+				changes[i].New = redact(c.New)
+			}
+		}
+	}
+
+	if *asJSON {
+		err = printChangesJSON(changes)
+	} else {
+		printChanges(changes)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		return exitStatus(1)
+	}
+
+	return nil
+}
+
+// redact replaces non-empty values with env.RedactedValue.
+func redact(value string) string {
+	if value == "" {
+		return value
+	}
+
+	return env.RedactedValue
+}
+
+func printChanges(changes []env.Change) {
+	for _, c := range changes {
+		switch c.Kind {
+		case env.Added:
+			fmt.Printf("+ %s=%s\n", c.Key, c.New)
+		case env.Removed:
+			fmt.Printf("- %s=%s\n", c.Key, c.Old)
+		case env.Changed:
+			fmt.Printf("~ %s=%s -> %s\n", c.Key, c.Old, c.New)
+		}
+	}
+}
+
+func printChangesJSON(changes []env.Change) error {
+	type change struct {
+		Key  string `json:"key"`
+		Kind string `json:"kind"`
+		Old  string `json:"old,omitempty"`
+		New  string `json:"new,omitempty"`
+	}
+
+	out := make([]change, len(changes))
+	for i, c := range changes {
+		out[i] = change{Key: c.Key, Kind: c.Kind.String(), Old: c.Old, New: c.New}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
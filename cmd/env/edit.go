@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pchchv/env"
+)
+
+// get prints the value of a key read from env files.
+func get(args []string) error {
+	set := flag.NewFlagSet("get", flag.ExitOnError)
+	var envFilenames filenamesFlag
+	set.Var(&envFilenames, "f", "path or URL of a .env file, - for standard input (repeatable)")
+	keys, err := parseInterspersed(set, args)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) != 1 {
+		return errors.New("usage: env get [-f ENV_FILE_PATH]... KEY")
+	}
+
+	envMap, err := env.Read(envFilenames...)
+	if err != nil {
+		return err
+	}
+
+	value, ok := envMap[keys[0]]
+	if !ok {
+		return fmt.Errorf("%s is not set", keys[0])
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+// setKeys sets keys in an env file, creating it if it does not exist.
+func setKeys(args []string) error {
+	set := flag.NewFlagSet("set", flag.ExitOnError)
+	filename := set.String("f", ".env", "path to the .env file")
+	entries, err := parseInterspersed(set, args)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return errors.New("usage: env set [-f ENV_FILE_PATH] KEY=VALUE...")
+	}
+
+	doc, err := env.ReadDocument(*filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		doc, err = env.ParseDocument(nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range entries {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected KEY=VALUE, got %q", arg)
+		}
+		doc.Set(key, value)
+	}
+
+	return doc.Write(*filename)
+}
+
+// unsetKeys removes keys from an env file.
+func unsetKeys(args []string) error {
+	set := flag.NewFlagSet("unset", flag.ExitOnError)
+	filename := set.String("f", ".env", "path to the .env file")
+	keys, err := parseInterspersed(set, args)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return errors.New("usage: env unset [-f ENV_FILE_PATH] KEY...")
+	}
+
+	doc, err := env.ReadDocument(*filename)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		doc.Unset(key)
+	}
+
+	return doc.Write(*filename)
+}
+
+// list prints the keys of an env file in the order they are declared,
+// redacting the values of sensitive keys unless -reveal is given.
+func list(args []string) error {
+	set := flag.NewFlagSet("list", flag.ExitOnError)
+	filename := set.String("f", ".env", "path to the .env file")
+	format := set.String("format", "keys", "output format: keys, env or json")
+	reveal := set.Bool("reveal", false, "show the values of sensitive keys")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	doc, err := env.ReadDocument(*filename)
+	if err != nil {
+		return err
+	}
+
+	envMap, prov, err := env.ReadWithProvenance(*filename)
+	if err != nil {
+		return err
+	}
+
+	if !*reveal {
+		for key, value := range envMap {
+			envMap[key] = prov.Redact(key, value)
+		}
+	}
+
+	switch *format {
+	case "keys":
+		for _, key := range doc.Keys() {
+			fmt.Println(key)
+		}
+	case "env":
+		for _, key := range doc.Keys() {
+			line, err := env.Marshal(map[string]string{key: envMap[key]})
+			if err != nil {
+				return err
+			}
+			fmt.Println(line)
+		}
+	case "json":
+		content, err := json.MarshalIndent(envMap, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditFlagsAfterArguments(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := setKeys([]string{"A=1", "B=2", "-f", filename}); err != nil {
+		t.Fatalf("error setting keys: %v", err)
+	}
+
+	if err := unsetKeys([]string{"B", "-f", filename}); err != nil {
+		t.Fatalf("error unsetting keys: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "A=1\n"; string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+}
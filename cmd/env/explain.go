@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pchchv/env"
+)
+
+// explain prints where the value of a key comes from.
+func explain(args []string) error {
+	set := flag.NewFlagSet("explain", flag.ExitOnError)
+	var envFilenames filenamesFlag
+	set.Var(&envFilenames, "f", "path or URL of a .env file, - for standard input (repeatable)")
+	overload := set.Bool("o", false, "override existing .env variables")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	if set.NArg() != 1 {
+		return fmt.Errorf("usage: env explain [-o] [-f ENV_FILE_PATH]... KEY")
+	}
+	key := set.Arg(0)
+
+	_, existed := os.LookupEnv(key)
+	load := env.LoadWithProvenance
+	if *overload {
+		load = env.OverloadWithProvenance
+	}
+
+	prov, err := load(envFilenames...)
+	if err != nil {
+		return err
+	}
+
+	origins := prov[key]
+	value := prov.Redact(key, os.Getenv(key))
+	switch origin, ok := prov.Effective(key); {
+	case ok:
+		fmt.Printf("%s=%s is set by %s:%d\n", key, value, origin.File, origin.Line)
+	case existed:
+		fmt.Printf("%s=%s is set by the process environment\n", key, value)
+	default:
+		fmt.Printf("%s is not set\n", key)
+	}
+
+	for _, origin := range origins {
+		fmt.Printf("  %s:%d\t%s\n", origin.File, origin.Line, origin.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pchchv/env"
+)
+
+// exporter writes the variables with the given keys in some format.
+type exporter func(w io.Writer, keys []string, envMap map[string]string) error
+
+// export prints the variables read from env files in another format.
+func export(args []string) error {
+	set := flag.NewFlagSet("export", flag.ExitOnError)
+	var envFilenames filenamesFlag
+	set.Var(&envFilenames, "f", "path or URL of a .env file, - for standard input (repeatable)")
+	format := set.String("format", "sh", "output format: sh, fish, powershell, json, yaml, docker, systemd or k8s-configmap")
+	name := set.String("name", "env", "name of the ConfigMap for -format k8s-configmap")
+	prefix := set.String("prefix", "", "export only the keys starting with the prefix")
+	strip := set.Bool("strip", false, "remove the prefix given with -prefix from the keys")
+	addPrefix := set.String("add-prefix", "", "prefix added to the exported keys")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	exporters := map[string]exporter{
+		"sh":            exportShell,
+		"fish":          exportFish,
+		"powershell":    exportPowerShell,
+		"json":          exportJSON,
+		"yaml":          exportYAML,
+		"docker":        exportDocker,
+		"systemd":       exportSystemd,
+		"k8s-configmap": exportConfigMap(*name),
+	}
+	exporter, ok := exporters[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	loader := &env.Loader{Prefix: *prefix, StripPrefix: *strip}
+	envMap, err := loader.Read(envFilenames...)
+	if err != nil {
+		return err
+	}
+
+	if *addPrefix != "" {
+		envMap = env.AddPrefix(envMap, *addPrefix)
+	}
+
+	keys := make([]string, 0, len(envMap))
+	for key := range envMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return exporter(os.Stdout, keys, envMap)
+}
+
+// exportShell writes POSIX shell export statements with single-quoted values.
+func exportShell(w io.Writer, keys []string, envMap map[string]string) error {
+	if err := checkShellNames(keys); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		value := strings.ReplaceAll(envMap[key], `'`, `'\''`)
+		if _, err := fmt.Fprintf(w, "export %s='%s'\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportFish writes fish set statements with single-quoted values.
+func exportFish(w io.Writer, keys []string, envMap map[string]string) error {
+	if err := checkShellNames(keys); err != nil {
+		return err
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "set -gx %s '%s'\n", key, replacer.Replace(envMap[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkShellNames returns an error if a key is not a valid shell variable name,
+// such as a.b, which the parser accepts but would break the generated script.
+func checkShellNames(keys []string) error {
+	for _, key := range keys {
+		if !isShellName(key) {
+			return fmt.Errorf("%s is not a valid shell variable name", key)
+		}
+	}
+
+	return nil
+}
+
+// isShellName tells whether the name matches [A-Za-z_][A-Za-z0-9_]*.
+func isShellName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// exportPowerShell writes PowerShell assignments with single-quoted values.
+func exportPowerShell(w io.Writer, keys []string, envMap map[string]string) error {
+	for _, key := range keys {
+		value := strings.ReplaceAll(envMap[key], `'`, `''`)
+		if _, err := fmt.Fprintf(w, "${env:%s} = '%s'\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportJSON writes a JSON object.
+func exportJSON(w io.Writer, keys []string, envMap map[string]string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(envMap)
+}
+
+// exportYAML writes a YAML map.
+// Keys and values are double-quoted, so that keys such as NO are not read as booleans.
+func exportYAML(w io.Writer, keys []string, envMap map[string]string) error {
+	return writeYAMLMap(w, "", keys, envMap)
+}
+
+// exportDocker writes a file for docker run --env-file, which does not support quoting.
+func exportDocker(w io.Writer, keys []string, envMap map[string]string) error {
+	for _, key := range keys {
+		if strings.ContainsAny(envMap[key], "\r\n") {
+			return fmt.Errorf("value of %s contains a line break, which docker env files do not support", key)
+		}
+	}
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, envMap[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportSystemd writes a file for the EnvironmentFile directive of systemd units.
+func exportSystemd(w io.Writer, keys []string, envMap map[string]string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=\"%s\"\n", key, replacer.Replace(envMap[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportConfigMap returns an exporter writing a Kubernetes ConfigMap manifest with the given name.
+func exportConfigMap(name string) exporter {
+	return func(w io.Writer, keys []string, envMap map[string]string) error {
+		data := "data:"
+		if len(keys) == 0 {
+			data += " {}"
+		}
+
+		_, err := fmt.Fprintf(w, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n%s\n", quoteJSON(name), data)
+		if err != nil {
+			return err
+		}
+
+		return writeYAMLMap(w, "  ", keys, envMap)
+	}
+}
+
+func writeYAMLMap(w io.Writer, indent string, keys []string, envMap map[string]string) error {
+	for _, key := range keys {
+		// JSON strings are valid double-quoted YAML scalars
+		if _, err := fmt.Fprintf(w, "%s%s: %s\n", indent, quoteJSON(key), quoteJSON(envMap[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quoteJSON returns s as a JSON string.
+func quoteJSON(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	// encoding a string cannot fail
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
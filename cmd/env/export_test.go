@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+// tricky holds values that need escaping in most formats.
+var tricky = map[string]string{
+	"A": `it's "quoted"`,
+	"B": `back\slash`,
+	"C": "$HOME and `cmd`",
+	"D": "line1\nline2",
+}
+
+var trickyKeys = []string{"A", "B", "C", "D"}
+
+func TestExporters(t *testing.T) {
+	tests := map[string]struct {
+		exporter exporter
+		expected string
+	}{
+		"sh": {exportShell, "export A='it'\\''s \"quoted\"'\n" +
+			"export B='back\\slash'\n" +
+			"export C='$HOME and `cmd`'\n" +
+			"export D='line1\nline2'\n"},
+		"fish": {exportFish, "set -gx A 'it\\'s \"quoted\"'\n" +
+			"set -gx B 'back\\\\slash'\n" +
+			"set -gx C '$HOME and `cmd`'\n" +
+			"set -gx D 'line1\nline2'\n"},
+		"powershell": {exportPowerShell, "${env:A} = 'it''s \"quoted\"'\n" +
+			"${env:B} = 'back\\slash'\n" +
+			"${env:C} = '$HOME and `cmd`'\n" +
+			"${env:D} = 'line1\nline2'\n"},
+		"systemd": {exportSystemd, "A=\"it's \\\"quoted\\\"\"\n" +
+			"B=\"back\\\\slash\"\n" +
+			"C=\"\\$HOME and \\`cmd\\`\"\n" +
+			"D=\"line1\nline2\"\n"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := test.exporter(&b, trickyKeys, tricky); err != nil {
+				t.Fatalf("error exporting: %v", err)
+			}
+
+			if b.String() != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestExportShellEval(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	var b bytes.Buffer
+	if err = exportShell(&b, trickyKeys, tricky); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+
+	for _, key := range trickyKeys {
+		out, err := exec.Command(shell, "-c", b.String()+`printf %s "$`+key+`"`).Output()
+		if err != nil {
+			t.Fatalf("error running the script: %v", err)
+		}
+
+		if string(out) != tricky[key] {
+			t.Errorf("Expected %s to be %q, got %q", key, tricky[key], out)
+		}
+	}
+}
+
+func TestExportDockerLineBreak(t *testing.T) {
+	var b bytes.Buffer
+	if err := exportDocker(&b, trickyKeys, tricky); err == nil {
+		t.Error("Expected an error exporting a value with a line break")
+	}
+
+	if b.Len() != 0 {
+		t.Errorf("Expected nothing to be written, got %q", b.String())
+	}
+}
+
+func TestExportInvalidShellName(t *testing.T) {
+	envMap := map[string]string{"a.b": "x"}
+	for name, exporter := range map[string]exporter{"sh": exportShell, "fish": exportFish} {
+		var b bytes.Buffer
+		if err := exporter(&b, []string{"a.b"}, envMap); err == nil {
+			t.Errorf("Expected %s to reject the key a.b", name)
+		}
+	}
+}
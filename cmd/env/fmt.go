@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pchchv/env"
+)
+
+// format prints env files in canonical form, or rewrites them with -w.
+func format(args []string) error {
+	set := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := set.Bool("w", false, "rewrite the files instead of printing them")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	filenames := set.Args()
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	failed := false
+	for _, filename := range filenames {
+		if err := formatFile(filename, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		return exitStatus(1)
+	}
+
+	return nil
+}
+
+// formatFile prints the file in canonical form, or rewrites it if write is true and it is not canonical.
+func formatFile(filename string, write bool) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := env.Format(src)
+	if err != nil {
+		return err
+	}
+
+	if !write {
+		_, err = os.Stdout.Write(formatted)
+		return err
+	}
+
+	if bytes.Equal(src, formatted) {
+		return nil
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, formatted, info.Mode().Perm())
+}
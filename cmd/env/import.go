@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pchchv/env"
+)
+
+// importFile converts a file in another format into an env file.
+func importFile(args []string) error {
+	set := flag.NewFlagSet("import", flag.ExitOnError)
+	format := set.String("format", "", "input format: "+strings.Join(env.ImportFormats, ", ")+" (guessed from the extension by default)")
+	separator := set.String("sep", "__", "separator joining nested keys")
+	out := set.String("out", "", "path of the .env file to write (standard output by default)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	var envMap map[string]string
+	var err error
+	switch filename := set.Arg(0); {
+	case set.NArg() > 1:
+		return errors.New("usage: env import [-format FORMAT] [-sep SEPARATOR] [-out ENV_FILE_PATH] [FILE]")
+	case filename == "" || filename == "-":
+		if *format == "" {
+			return errors.New("-format is required when reading standard input")
+		}
+		envMap, err = env.Import(os.Stdin, *format, *separator)
+	default:
+		envMap, err = env.ImportFile(filename, *format, *separator)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		return env.Write(envMap, *out)
+	}
+
+	content, err := env.Marshal(envMap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(content)
+	return nil
+}
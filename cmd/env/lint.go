@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pchchv/env"
+)
+
+// lint reports problems in env files, optionally fixing them,
+// and exits with status 1 if any remain.
+func lint(args []string) error {
+	set := flag.NewFlagSet("lint", flag.ExitOnError)
+	fix := set.Bool("fix", false, "rewrite the files, fixing the problems that can be fixed")
+	format := set.String("format", "text", "output format: text (FILE:LINE: RULE: MESSAGE) or json")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	filenames := set.Args()
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	type issue struct {
+		File string `json:"file"`
+		env.Issue
+	}
+	var issues []issue
+	failed := false
+	for _, filename := range filenames {
+		fileIssues, err := lintFile(filename, *fix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+			continue
+		}
+
+		for _, i := range fileIssues {
+			issues = append(issues, issue{File: filename, Issue: i})
+		}
+	}
+
+	if *format == "json" {
+		if issues == nil {
+			issues = []issue{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, i := range issues {
+			fmt.Printf("%s:%s\n", i.File, i.Issue)
+		}
+	}
+
+	if failed || len(issues) > 0 {
+		return exitStatus(1)
+	}
+
+	return nil
+}
+
+// lintFile returns the problems in the file, after fixing it if fix is true.
+func lintFile(filename string, fix bool) ([]env.Issue, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if fix {
+		fixed, err := env.Fix(src)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = os.WriteFile(filename, fixed, info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		src = fixed
+	}
+
+	return env.Lint(src)
+}
@@ -1,31 +1,123 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/pchchv/env"
 )
 
+// commands maps subcommand names to their implementations.
+// Each one receives the arguments that follow its name.
+var commands = map[string]func(args []string) error{
+	"explain":    explain,
+	"print":      printEnv,
+	"get":        get,
+	"set":        setKeys,
+	"unset":      unsetKeys,
+	"list":       list,
+	"export":     export,
+	"import":     importFile,
+	"diff":       diff,
+	"lint":       lint,
+	"fmt":        format,
+	"encrypt":    encrypt,
+	"decrypt":    decrypt,
+	"rotate-key": rotateKey,
+}
+
+// exitStatus is returned by commands to exit with the status without reporting an error.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			err := run(os.Args[2:])
+			var status exitStatus
+			if errors.As(err, &status) {
+				os.Exit(int(status))
+			} else if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	var showHelp bool
 	flag.BoolVar(&showHelp, "h", false, "show help")
-	var rawEnvFilenames string
-	flag.StringVar(&rawEnvFilenames, "f", "", "comma separated paths to .env files")
+	var envFilenames filenamesFlag
+	flag.Var(&envFilenames, "f", "path or URL of a .env file, - for standard input (repeatable)")
+	var inline listFlag
+	flag.Var(&inline, "e", "KEY=VALUE set for the command, overriding the files and the environment (repeatable)")
 	var overload bool
 	flag.BoolVar(&overload, "o", false, "override existing .env variables")
+	var replaceProcess bool
+	flag.BoolVar(&replaceProcess, "x", false, "replace env with the command instead of running it as a child")
+	var clean bool
+	flag.BoolVar(&clean, "i", false, "start with an empty environment, keeping only the variables listed in -keep")
+	var rawKeep string
+	flag.StringVar(&rawKeep, "keep", "", "comma separated variables kept from the environment with -i")
+	var prefix string
+	flag.StringVar(&prefix, "prefix", "", "load only the keys starting with the prefix")
+	var strip bool
+	flag.BoolVar(&strip, "strip", false, "remove the prefix given with -prefix from the keys")
 
 	flag.Parse()
 
 	usage := `
 Run a process with an env setup from a .env file
-env [-o] [-f ENV_FILE_PATHS] COMMAND_ARGS
-ENV_FILE_PATHS: comma separated paths to .env files
+env [-o] [-x] [-i [-keep VARS]] [-prefix PREFIX [-strip]] [-f ENV_FILE_PATH]... [-e KEY=VALUE]... COMMAND_ARGS
+ENV_FILE_PATH: path or URL of a .env file, - for standard input; repeat -f to load several files
+-e: set KEY for the command, overriding the files and the environment
+-prefix: load only the keys of the files starting with PREFIX (e.g. BILLING_), without it with -strip
+-x: replace the env process with the command (exec), e.g. as a container entrypoint
+-i: run the command only with the variables from the files
+    and the comma separated VARS of the current environment (e.g. PATH,HOME,TERM)
 COMMAND_ARGS: command and args you want to run
 example
-  env -f /path/to/something/.env,/another/path/.env fortune
+  env -f /path/to/something/.env -f /another/path/.env -e LANG=C fortune
+  sops -d secrets.env | env -f - -f .env ./server
+
+Other commands
+  env get [-f ENV_FILE_PATH]... KEY
+    print the value of KEY
+  env set [-f ENV_FILE_PATH] KEY=VALUE...
+    set keys in the file (.env by default), keeping its comments and order
+  env unset [-f ENV_FILE_PATH] KEY...
+    remove keys from the file
+  env list [-f ENV_FILE_PATH] [-format keys|env|json] [-reveal]
+    list the keys of the file in order, or their values (hiding secrets unless -reveal is given)
+  env export [-f ENV_FILE_PATH]... [-format FORMAT] [-name NAME] [-prefix PREFIX [-strip]] [-add-prefix PREFIX]
+    print the variables as sh (default), fish, powershell, json, yaml,
+    docker (--env-file), systemd (EnvironmentFile) or k8s-configmap (named NAME),
+    only those starting with -prefix and with -add-prefix added to the keys
+  env import [-format FORMAT] [-sep SEPARATOR] [-out ENV_FILE_PATH] [FILE]
+    convert a json, yaml, toml, properties or docker env file (or standard input) into an env file,
+    joining nested keys with SEPARATOR (__ by default)
+  env diff [-json] [-reveal] OLD_ENV_FILE (NEW_ENV_FILE | -live)
+    show added, removed and changed keys between two files or a file and the current environment,
+    exiting with status 1 if there are differences
+  env lint [-fix] [-format text|json] [ENV_FILE_PATH...]
+    report problems such as duplicate keys or undefined variables, optionally fixing them
+  env fmt [-w] [ENV_FILE_PATH...]
+    print the files in canonical form (spacing, quoting, sorted keys), or rewrite them with -w
+  env explain [-o] [-f ENV_FILE_PATH]... KEY
+    show which file and line set KEY
+  env print [-f ENV_FILE_PATH]...
+    print the variables defined in the files, hiding secrets
+  env encrypt|decrypt [-f ENV_FILE_PATH]... [-k KEYFILE] [KEY...]
+    encrypt or decrypt values in place (all values if no KEY is given)
+  env rotate-key [-f ENV_FILE_PATH]... [-k KEYFILE]
+    re-encrypt values with a new key and replace the keyfile
 `
 	// if no args or -h flag
 	// print usage and return
@@ -35,18 +127,103 @@ example
 		return
 	}
 
-	// load env
-	var envFilenames []string
-	if rawEnvFilenames != "" {
-		envFilenames = strings.Split(rawEnvFilenames, ",")
+	for _, kv := range inline {
+		if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+			log.Fatalf("invalid -e %q, expected KEY=VALUE", kv)
+		}
 	}
 
 	// take rest of args and "exec" them
 	cmd := args[0]
 	cmdArgs := args[1:]
 
-	err := env.Exec(envFilenames, cmd, cmdArgs, overload)
+	loader := &env.Loader{Clean: clean, Prefix: prefix, StripPrefix: strip}
+	if rawKeep != "" {
+		loader.Keep = strings.Split(rawKeep, ",")
+	}
+
+	if replaceProcess {
+		environ, err := loader.Environ(nil, envFilenames, overload)
+		if err == nil {
+			err = replace(setEnviron(environ, inline), cmd, cmdArgs)
+		}
+		log.Fatal(err)
+	}
+
+	command, err := loader.Command(context.Background(), envFilenames, overload, cmd, cmdArgs...)
 	if err != nil {
 		log.Fatal(err)
 	}
+	command.Env = setEnviron(command.Env, inline)
+
+	err = env.Run(command)
+	var exitErr *env.ExitError
+	if errors.As(err, &exitErr) {
+		// exit with the status of the command, it has already reported the problem
+		os.Exit(exitErr.Code)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseInterspersed parses the flags of the set, which may be mixed with the positional arguments,
+// and returns the positional arguments.
+func parseInterspersed(set *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := set.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if set.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, set.Arg(0))
+		args = set.Args()[1:]
+	}
+}
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// filenamesFlag collects the paths given to repeated -f flags.
+// Each flag names a single file, which may contain commas.
+type filenamesFlag []string
+
+func (f *filenamesFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *filenamesFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// setEnviron returns the environment with the "KEY=VALUE" entries set, replacing existing keys.
+func setEnviron(environ []string, entries []string) []string {
+	for _, kv := range entries {
+		key, _, _ := strings.Cut(kv, "=")
+		replaced := false
+		for i, existing := range environ {
+			if strings.HasPrefix(existing, key+"=") {
+				environ[i], replaced = kv, true
+			}
+		}
+
+		if !replaced {
+			environ = append(environ, kv)
+		}
+	}
+
+	return environ
 }
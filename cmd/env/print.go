@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/pchchv/env"
+)
+
+// printEnv prints the variables defined by env files, redacting the values of sensitive keys.
+func printEnv(args []string) error {
+	set := flag.NewFlagSet("print", flag.ExitOnError)
+	var envFilenames filenamesFlag
+	set.Var(&envFilenames, "f", "path or URL of a .env file, - for standard input (repeatable)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	envMap, prov, err := env.ReadWithProvenance(envFilenames...)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range envMap {
+		envMap[key] = prov.Redact(key, value)
+	}
+
+	content, err := env.Marshal(envMap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(content)
+	return nil
+}
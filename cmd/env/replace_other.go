@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// replace is not supported on this platform.
+func replace(environ []string, cmd string, cmdArgs []string) error {
+	return errors.New("-x is not supported on this platform")
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// replace replaces the current process with cmd running in environ,
+// so it keeps the PID, receives signals directly and its exit status is that of env.
+// It only returns if the command cannot be started.
+func replace(environ []string, cmd string, cmdArgs []string) error {
+	// look the command up in the PATH it will run with
+	for _, kv := range environ {
+		if path, ok := strings.CutPrefix(kv, "PATH="); ok {
+			if err := os.Setenv("PATH", path); err != nil {
+				return err
+			}
+		}
+	}
+
+	path, err := exec.LookPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(path, append([]string{cmd}, cmdArgs...), environ)
+}
@@ -0,0 +1,203 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix marks values encrypted with Encrypt.
+	EncryptedPrefix = "enc:v1:"
+	// KeyEnv is the environment variable holding the base64 encoded encryption key.
+	KeyEnv = "ENV_KEY"
+	// KeyFileEnv is the environment variable holding the path to the keyfile.
+	KeyFileEnv = "ENV_KEY_FILE"
+	// DefaultKeyFile is the keyfile used when neither KeyEnv nor KeyFileEnv is set.
+	DefaultKeyFile = ".env.key"
+	// KeySize is the size of the encryption key in bytes (AES-256).
+	KeySize = 32
+)
+
+// GenerateKey returns a new random encryption key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// EncodeKey returns the textual form of the key as stored in keyfiles and in KeyEnv.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// DecodeKey parses a key in the form produced by EncodeKey.
+func DecodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid encryption key: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// ReadKey returns the encryption key from the KeyEnv environment variable or,
+// if it is not set, from the keyfile named by KeyFileEnv (DefaultKeyFile by default).
+func ReadKey() ([]byte, error) {
+	if s, ok := os.LookupEnv(KeyEnv); ok {
+		return DecodeKey(s)
+	}
+
+	return ReadKeyFile(KeyFilename())
+}
+
+// KeyFilename returns the path of the keyfile used by ReadKey.
+func KeyFilename() string {
+	if filename := os.Getenv(KeyFileEnv); filename != "" {
+		return filename
+	}
+
+	return DefaultKeyFile
+}
+
+// ReadKeyFile reads the encryption key from the file.
+func ReadKeyFile(filename string) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeKey(string(content))
+}
+
+// WriteKeyFile writes the encryption key to the file,
+// making it readable only by the owner.
+// The key is written to a temporary file renamed over the file,
+// so that an existing key is never left truncated.
+func WriteKeyFile(key []byte, filename string) error {
+	return writeFile(filename, []byte(EncodeKey(key)+"\n"), 0o600)
+}
+
+// writeFile writes data to a temporary file in the directory of filename with the permissions perm
+// and renames it over filename once it is synced.
+func writeFile(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// IsEncrypted tells whether the value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// Encrypt encrypts the value of the named variable with AES-256-GCM.
+// The name is authenticated along with the value,
+// so an encrypted value cannot be moved to another variable.
+func Encrypt(key []byte, name, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return EncryptedPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the value of the named variable produced by Encrypt.
+func Decrypt(key []byte, name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("value of %s is not encrypted", name)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value of %s", name)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value of %s", name)
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt value of %s: %w", name, err)
+	}
+
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("invalid encryption key size")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decrypter returns a parse hook decrypting encrypted values.
+// The key is only read once the first encrypted value is found.
+func decrypter() func(key, value string) (string, error) {
+	var secret []byte
+	return func(key, value string) (string, error) {
+		if !IsEncrypted(value) {
+			return value, nil
+		}
+
+		if secret == nil {
+			k, err := ReadKey()
+			if err != nil {
+				return "", fmt.Errorf("cannot decrypt value of %s: %w", key, err)
+			}
+			secret = k
+		}
+
+		return Decrypt(secret, key, value)
+	}
+}
@@ -0,0 +1,63 @@
+package env
+
+import "sort"
+
+// ChangeKind tells how a key differs between two sets of variables.
+type ChangeKind int
+
+const (
+	// Added means the key only exists in the new set.
+	Added ChangeKind = iota
+	// Removed means the key only exists in the old set.
+	Removed
+	// Changed means the key exists in both sets with different values.
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a key that differs between two sets of variables.
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	// Old is the value in the old set, empty if the key was added.
+	Old string
+	// New is the value in the new set, empty if the key was removed.
+	New string
+}
+
+// Diff returns the keys that differ between the old and the new set of variables, sorted by key.
+func Diff(oldMap, newMap map[string]string) []Change {
+	var changes []Change
+	for key, oldValue := range oldMap {
+		newValue, ok := newMap[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: Removed, Old: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, Change{Key: key, Kind: Changed, Old: oldValue, New: newValue})
+		}
+	}
+
+	for key, newValue := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: Added, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
@@ -0,0 +1,231 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Document is an env file that can be edited without losing its comments,
+// blank lines and the order of its declarations.
+// Line endings are normalized to LF.
+type Document struct {
+	nodes []*node
+}
+
+// node is a part of a Document: either whole lines without declarations (comments and blank lines)
+// or a line (or several, for multi-line quoted values) holding one declaration, including its line break.
+// Several declarations on the same line are separate nodes, the line break belonging to the last one.
+type node struct {
+	// key is empty for lines without declarations
+	key string
+	// for declarations, prefix holds everything before the raw value (indentation, export, key and separator),
+	// and suffix everything after it (spaces, inline comment and line break);
+	// for other lines, prefix holds the text
+	prefix, value, suffix string
+}
+
+func (n *node) String() string {
+	return n.prefix + n.value + n.suffix
+}
+
+// ParseDocument parses the source of an env file into a Document.
+func ParseDocument(src []byte) (*Document, error) {
+	src = bytes.Replace(src, []byte("\r\n"), []byte("\n"), -1)
+	doc := &Document{}
+	offset := 0
+	err := parseBytesFunc(src, make(map[string]string), func(d *declaration) error {
+		lineStart := bytes.LastIndexByte(src[:d.start], '\n') + 1
+		if lineStart < offset {
+			// the declaration shares a line with the previous one:
+			// the previous node ends with the spaces separating them
+			last := doc.nodes[len(doc.nodes)-1]
+			last.suffix = last.suffix[:len(last.suffix)-(offset-d.start)]
+			lineStart = d.start
+		}
+
+		lineEnd := len(src)
+		if i := bytes.IndexByte(src[d.valueEnd:], '\n'); i != -1 {
+			lineEnd = d.valueEnd + i + 1
+		}
+
+		valueEnd := d.valueStart + len(bytes.TrimRightFunc(src[d.valueStart:d.valueEnd], isSpace))
+		if lineStart > offset {
+			doc.nodes = append(doc.nodes, &node{prefix: string(src[offset:lineStart])})
+		}
+		doc.nodes = append(doc.nodes, &node{
+			key:    d.key,
+			prefix: string(src[lineStart:d.valueStart]),
+			value:  string(src[d.valueStart:valueEnd]),
+			suffix: string(src[valueEnd:lineEnd]),
+		})
+		offset = lineEnd
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if offset < len(src) {
+		doc.nodes = append(doc.nodes, &node{prefix: string(src[offset:])})
+	}
+
+	return doc, nil
+}
+
+// ReadDocument reads the env file into a Document.
+func ReadDocument(filename string) (*Document, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDocument(src)
+}
+
+// Keys returns the keys declared in the document in the order of their first declaration.
+func (doc *Document) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, n := range doc.nodes {
+		if n.key != "" && !seen[n.key] {
+			seen[n.key] = true
+			keys = append(keys, n.key)
+		}
+	}
+
+	return keys
+}
+
+// Map returns the keys and values of the document as they are read by Unmarshal.
+func (doc *Document) Map() (map[string]string, error) {
+	return Unmarshal(doc.String())
+}
+
+// Get returns the value of the key as it is read by Unmarshal.
+func (doc *Document) Get(key string) (string, bool) {
+	envMap, err := doc.Map()
+	if err != nil {
+		return "", false
+	}
+
+	value, ok := envMap[key]
+	return value, ok
+}
+
+// Literal returns the value of the last declaration of the key as written in the document,
+// with its quotes and escapes removed but without expanding variable references.
+// It reports false if the key is not declared or its value references other variables,
+// which are only known once expanded.
+func (doc *Document) Literal(key string) (string, bool) {
+	for i := len(doc.nodes) - 1; i >= 0; i-- {
+		if n := doc.nodes[i]; n.key == key {
+			return literalValue(n.value)
+		}
+	}
+
+	return "", false
+}
+
+// Set sets the value of the key, formatted as by Marshal.
+// The last declaration of the key is updated in place, keeping its inline comment,
+// a new declaration is appended to the end of the document if there is none.
+func (doc *Document) Set(key, value string) {
+	for i := len(doc.nodes) - 1; i >= 0; i-- {
+		if n := doc.nodes[i]; n.key == key {
+			n.value = formatValue(value)
+			return
+		}
+	}
+
+	if len(doc.nodes) > 0 {
+		if last := doc.nodes[len(doc.nodes)-1]; !strings.HasSuffix(last.String(), "\n") {
+			last.suffix += "\n"
+		}
+	}
+
+	doc.nodes = append(doc.nodes, &node{key: key, prefix: key + "=", value: formatValue(value), suffix: "\n"})
+}
+
+// Unset removes all declarations of the key, reporting whether there were any.
+func (doc *Document) Unset(key string) bool {
+	removed := make(map[int]bool)
+	for i, n := range doc.nodes {
+		if n.key == key {
+			removed[i] = true
+		}
+	}
+
+	doc.nodes = withoutNodes(doc.nodes, removed)
+	return len(removed) > 0
+}
+
+// withoutNodes returns the nodes except those at the removed indexes.
+// A declaration followed on its line by a removed one gets its line break,
+// in a copy so that nodes is left unchanged.
+func withoutNodes(nodes []*node, removed map[int]bool) []*node {
+	result := make([]*node, 0, len(nodes))
+	for i, n := range nodes {
+		if !removed[i] {
+			result = append(result, n)
+			continue
+		}
+
+		if len(result) == 0 || strings.HasSuffix(result[len(result)-1].String(), "\n") {
+			continue
+		}
+
+		// the removed node shares a line with the previous one
+		prev := *result[len(result)-1]
+		if strings.HasSuffix(n.suffix, "\n") {
+			prev.suffix = strings.TrimRightFunc(prev.suffix, isSpace) + "\n"
+		} else if i == len(nodes)-1 {
+			prev.suffix = strings.TrimRightFunc(prev.suffix, isSpace)
+		}
+		result[len(result)-1] = &prev
+	}
+
+	return result
+}
+
+// joined tells whether the node at index i is followed by another declaration on the same line.
+func (doc *Document) joined(i int) bool {
+	return i+1 < len(doc.nodes) && !strings.HasSuffix(doc.nodes[i].String(), "\n")
+}
+
+// walk calls fn for every node with the 1-based number of its first line.
+func (doc *Document) walk(fn func(n *node, line int)) {
+	line := 1
+	for _, n := range doc.nodes {
+		fn(n, line)
+		line += strings.Count(n.String(), "\n")
+	}
+}
+
+// String returns the source of the document.
+func (doc *Document) String() string {
+	var b strings.Builder
+	for _, n := range doc.nodes {
+		b.WriteString(n.String())
+	}
+
+	return b.String()
+}
+
+// Write writes the document to the file, preserving the permissions of an existing file.
+// The document is written to a temporary file renamed over the file,
+// so that the file is never left partially written.
+func (doc *Document) Write(filename string) error {
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	// replace the target of a symbolic link rather than the link
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+
+	return writeFile(filename, []byte(doc.String()), perm)
+}
@@ -2,7 +2,7 @@ package env
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"io"
 	"os"
 	"os/exec"
@@ -36,15 +36,7 @@ func Parse(r io.Reader) (map[string]string, error) {
 // It is important to note that it DOES NOT DELETE env variables that already exist -
 // use the .env file to set dev vars or reasonable defaults.
 func Load(filenames ...string) (err error) {
-	filenames = filenamesOrDefault(filenames)
-	for _, filename := range filenames {
-		err = loadFile(filename, false)
-		if err != nil {
-			return
-		}
-	}
-
-	return
+	return NewLoader().Load(filenames...)
 }
 
 // Overload reads your env file(s) and loads them into ENV for this process.
@@ -57,41 +49,20 @@ func Load(filenames ...string) (err error) {
 // It is important to note that this OVERRIDE an env variable that already exists -
 // think of the .env file as forcibly setting all variables.
 func Overload(filenames ...string) (err error) {
-	filenames = filenamesOrDefault(filenames)
-	for _, filename := range filenames {
-		err = loadFile(filename, true)
-		if err != nil {
-			return
-		}
-	}
-
-	return
+	return NewLoader().Overload(filenames...)
 }
 
 // Read reads all envs (with the same load semantics as Load),
 // but returns the values as a map instead of automatically writing them to the env.
+// Files can also be named by URIs such as https://config.internal/app.env, see ParseSource.
 func Read(filenames ...string) (envMap map[string]string, err error) {
-	filenames = filenamesOrDefault(filenames)
-	envMap = make(map[string]string)
-
-	for _, filename := range filenames {
-		individualEnvMap, individualErr := readFile(filename)
-
-		if individualErr != nil {
-			err = individualErr
-			return
-		}
-
-		for key, value := range individualEnvMap {
-			envMap[key] = value
-		}
-	}
-
-	return
+	return NewLoader().Read(filenames...)
 }
 
 // Exec loads the env vars from the specified filenames, then executes the specified command.
-// Simply connect os.stdin/err/out to the command and call Run().
+// Simply connect os.stdin/err/out to the command and run it,
+// forwarding signals received by this process (e.g. SIGINT and SIGTERM) to the command.
+// If the command exits with a non-zero status, the returned error is an *ExitError carrying it.
 // If you need finer command control,
 // recommend using `Load()`, `Overload()` or `Read()` and the `os/exec` package.
 func Exec(filenames []string, cmd string, cmdArgs []string, overload bool) error {
@@ -107,7 +78,31 @@ func Exec(filenames []string, cmd string, cmdArgs []string, overload bool) error
 	command.Stdin = os.Stdin
 	command.Stdout = os.Stdout
 	command.Stderr = os.Stderr
-	return command.Run()
+	return Run(command)
+}
+
+// ExecWithEnv executes the specified command like Exec,
+// but instead of loading the env vars into the environment of this process,
+// passes them only to the command, as computed by Environ.
+func ExecWithEnv(filenames []string, cmd string, cmdArgs []string, overload bool) error {
+	return NewLoader().ExecWithEnv(filenames, cmd, cmdArgs, overload)
+}
+
+// Environ returns the environment for a child process as a list of "key=value" strings:
+// base (the environment of the current process if nil) merged with the env vars from the specified filenames,
+// following the same precedence as Load, or Overload if overload is true.
+// The environment of the current process is left untouched.
+func Environ(base []string, filenames []string, overload bool) ([]string, error) {
+	return NewLoader().Environ(base, filenames, overload)
+}
+
+// Command returns a command running name with args in the environment built by Environ
+// from the environment of this process and the specified filenames.
+// Unlike Exec, it does not modify the environment of this process,
+// and the returned *exec.Cmd can be customized before it is started.
+// The command is killed if ctx is done before it exits.
+func Command(ctx context.Context, filenames []string, overload bool, name string, args ...string) (*exec.Cmd, error) {
+	return NewLoader().Command(ctx, filenames, overload, name, args...)
 }
 
 // Marshal outputs the given environment as a dotenv format environment file.
@@ -115,11 +110,7 @@ func Exec(filenames []string, cmd string, cmdArgs []string, overload bool) error
 func Marshal(envMap map[string]string) (string, error) {
 	lines := make([]string, 0, len(envMap))
 	for k, v := range envMap {
-		if d, err := strconv.Atoi(v); err == nil {
-			lines = append(lines, fmt.Sprintf(`%s=%d`, k, d))
-		} else {
-			lines = append(lines, fmt.Sprintf(`%s="%s"`, k, doubleQuoteEscape(v)))
-		}
+		lines = append(lines, k+"="+formatValue(v))
 	}
 	sort.Strings(lines)
 	return strings.Join(lines, "\n"), nil
@@ -161,6 +152,18 @@ func Write(envMap map[string]string, filename string) error {
 	return file.Sync()
 }
 
+// AddPrefix returns a copy of the map with the prefix added to every key,
+// e.g. to Write the variables of one service into a file shared with others.
+// It reverses reading with a Loader that has Prefix and StripPrefix set.
+func AddPrefix(envMap map[string]string, prefix string) map[string]string {
+	prefixed := make(map[string]string, len(envMap))
+	for key, value := range envMap {
+		prefixed[prefix+key] = value
+	}
+
+	return prefixed
+}
+
 func filenamesOrDefault(filenames []string) []string {
 	if len(filenames) == 0 {
 		return []string{".env"}
@@ -179,26 +182,14 @@ func readFile(filename string) (envMap map[string]string, err error) {
 	return Parse(file)
 }
 
-func loadFile(filename string, overload bool) error {
-	envMap, err := readFile(filename)
-	if err != nil {
-		return err
-	}
-
-	currentEnv := map[string]bool{}
-	rawEnv := os.Environ()
-	for _, rawEnvLine := range rawEnv {
-		key := strings.Split(rawEnvLine, "=")[0]
-		currentEnv[key] = true
-	}
-
-	for key, value := range envMap {
-		if !currentEnv[key] || overload {
-			_ = os.Setenv(key, value)
-		}
+// formatValue returns the value as written by Marshal:
+// integers as is, everything else double-quoted and backslash-escaped.
+func formatValue(v string) string {
+	if d, err := strconv.Atoi(v); err == nil {
+		return strconv.Itoa(d)
 	}
 
-	return nil
+	return `"` + doubleQuoteEscape(v) + `"`
 }
 
 func doubleQuoteEscape(line string) string {
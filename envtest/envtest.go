@@ -0,0 +1,76 @@
+// Package envtest sets environment variables for the duration of a test.
+//
+// Every function snapshots the environment of the process and restores it when the test ends,
+// unsetting the variables that did not exist before.
+// As with testing.T.Setenv, the environment is shared by the whole process:
+// these functions must not be used in parallel tests.
+package envtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pchchv/env"
+	"github.com/pchchv/env/internal/envkv"
+)
+
+// Load loads the files like env.Load for the duration of the test,
+// failing the test if they cannot be read.
+func Load(t testing.TB, filenames ...string) {
+	t.Helper()
+	restoreOnCleanup(t)
+	if err := env.Load(filenames...); err != nil {
+		t.Fatalf("envtest: %v", err)
+	}
+}
+
+// Overload loads the files like env.Overload for the duration of the test,
+// failing the test if they cannot be read.
+func Overload(t testing.TB, filenames ...string) {
+	t.Helper()
+	restoreOnCleanup(t)
+	if err := env.Overload(filenames...); err != nil {
+		t.Fatalf("envtest: %v", err)
+	}
+}
+
+// Set sets the variables for the duration of the test.
+func Set(t testing.TB, vars map[string]string) {
+	t.Helper()
+	restoreOnCleanup(t)
+	for key, value := range vars {
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatalf("envtest: %v", err)
+		}
+	}
+}
+
+// restoreOnCleanup snapshots the environment and restores it when the test ends.
+func restoreOnCleanup(t testing.TB) {
+	saved := environ()
+	t.Cleanup(func() {
+		for key := range environ() {
+			if _, ok := saved[key]; !ok {
+				_ = os.Unsetenv(key)
+			}
+		}
+
+		for key, value := range saved {
+			if current, ok := os.LookupEnv(key); !ok || current != value {
+				_ = os.Setenv(key, value)
+			}
+		}
+	})
+}
+
+// environ returns the environment of the process as a map.
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := envkv.Split(kv); ok {
+			vars[key] = value
+		}
+	}
+
+	return vars
+}
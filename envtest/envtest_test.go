@@ -0,0 +1,43 @@
+package envtest
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	t.Setenv("ENVTEST_KEPT", "before")
+
+	t.Run("set", func(t *testing.T) {
+		Set(t, map[string]string{"ENVTEST_KEPT": "during", "ENVTEST_NEW": "during"})
+		if os.Getenv("ENVTEST_KEPT") != "during" || os.Getenv("ENVTEST_NEW") != "during" {
+			t.Errorf("Expected the variables to be set, got %q and %q", os.Getenv("ENVTEST_KEPT"), os.Getenv("ENVTEST_NEW"))
+		}
+	})
+
+	if value := os.Getenv("ENVTEST_KEPT"); value != "before" {
+		t.Errorf("Expected ENVTEST_KEPT to be restored, got %q", value)
+	}
+
+	if _, ok := os.LookupEnv("ENVTEST_NEW"); ok {
+		t.Error("Expected ENVTEST_NEW to be unset")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	filename := t.TempDir() + "/.env"
+	if err := os.WriteFile(filename, []byte("ENVTEST_LOADED=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("load", func(t *testing.T) {
+		Load(t, filename)
+		if value := os.Getenv("ENVTEST_LOADED"); value != "1" {
+			t.Errorf("Expected ENVTEST_LOADED to be loaded, got %q", value)
+		}
+	})
+
+	if _, ok := os.LookupEnv("ENVTEST_LOADED"); ok {
+		t.Error("Expected ENVTEST_LOADED to be unset")
+	}
+}
@@ -0,0 +1,65 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// ExitError is returned by Exec and Run when the command does not exit successfully.
+type ExitError struct {
+	// Code is the exit status of the command.
+	// If the command was killed by a signal, it is 128 plus the signal number
+	// (as reported by shells) where the platform supports it.
+	Code int
+	Err  *exec.ExitError
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Run starts the command, forwards the signals received by this process to it until it exits,
+// and converts its non-zero exit status to an ExitError.
+// Signals sent by the terminal to its foreground process group, such as SIGINT on Ctrl-C,
+// are not forwarded when the command is in that group, as it already receives them.
+// It runs the commands of Exec and ExecWithEnv, and can run those built by Command once customized.
+func Run(command *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := command.Start(); err != nil {
+		return err
+	}
+
+	skipped := terminalSignals(command)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if !skipped[sig] {
+					_ = command.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := command.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitCode(exitErr), Err: exitErr}
+	}
+
+	return err
+}
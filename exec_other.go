@@ -0,0 +1,19 @@
+//go:build !unix
+
+package env
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are the signals Exec passes on to the command.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+func exitCode(err *exec.ExitError) int {
+	return err.ExitCode()
+}
+
+func terminalSignals(command *exec.Cmd) map[os.Signal]bool {
+	return nil
+}
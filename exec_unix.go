@@ -0,0 +1,42 @@
+//go:build unix
+
+package env
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are the signals Exec passes on to the command.
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGWINCH,
+}
+
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return err.ExitCode()
+}
+
+// terminalSignals returns the signals sent by the terminal to both this process and the command
+// when they share a process group in the foreground of the controlling terminal.
+func terminalSignals(command *exec.Cmd) map[os.Signal]bool {
+	if command.SysProcAttr != nil && (command.SysProcAttr.Setpgid || command.SysProcAttr.Setsid) {
+		return nil
+	}
+
+	if !isForeground() {
+		return nil
+	}
+
+	return map[os.Signal]bool{syscall.SIGINT: true, syscall.SIGQUIT: true}
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package env
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunForwardsSignals(t *testing.T) {
+	if shell == "" {
+		t.Skip("sh is not available")
+	}
+
+	command := exec.Command(shell, "-c", `trap 'exit 7' TERM; echo ready; while :; do sleep 0.1; done`)
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		// the trap is set once the command is ready, Run is then notified of the signals
+		if line, _ := bufio.NewReader(stdout).ReadString('\n'); line == "ready\n" {
+			_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		}
+	}()
+
+	// stop the command if the signal is not forwarded
+	timer := time.AfterFunc(10*time.Second, func() { _ = command.Process.Kill() })
+	defer timer.Stop()
+
+	err = Run(command)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 7 {
+		t.Errorf("Expected the command to exit with status 7 on SIGTERM, got %v", err)
+	}
+}
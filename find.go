@@ -0,0 +1,65 @@
+package env
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// projectMarkers are the files marking the root of a project, where Find stops searching.
+var projectMarkers = []string{"go.mod", ".git"}
+
+// Find returns the path of the file named name in the current directory or in the closest of its parents,
+// so that the .env file at the root of a project is found from any of its subdirectories.
+// The search stops at the root of the project (a directory containing go.mod or .git)
+// or of the filesystem.
+// An absolute name is returned as is if the file exists.
+// The error matches fs.ErrNotExist if the file is not found.
+func Find(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		if _, err := os.Stat(name); err != nil {
+			return "", err
+		}
+
+		return name, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	return findFrom(dir, name)
+}
+
+// findFrom searches for name in dir and its parents.
+func findFrom(dir, name string) (string, error) {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		if isProjectRoot(dir) {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", &fs.PathError{Op: "find", Path: name, Err: fs.ErrNotExist}
+}
+
+func isProjectRoot(dir string) bool {
+	for _, marker := range projectMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
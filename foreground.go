@@ -0,0 +1,23 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd
+
+package env
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isForeground tells whether the process group of this process is the foreground group of its controlling terminal.
+func isForeground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		// no controlling terminal
+		return false
+	}
+	defer tty.Close()
+
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	return errno == 0 && int(pgrp) == syscall.Getpgrp()
+}
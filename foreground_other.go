@@ -0,0 +1,9 @@
+//go:build unix && !(darwin || dragonfly || freebsd || linux || netbsd)
+
+package env
+
+// isForeground reports false where the foreground group of the terminal cannot be queried,
+// all signals are then forwarded.
+func isForeground() bool {
+	return false
+}
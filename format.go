@@ -0,0 +1,169 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Format returns the source of an env file in canonical form:
+//
+//   - declarations are written as KEY=value (keeping export), with a single space before inline comments
+//   - values are unquoted when they are plain words, and double-quoted when they contain spaces
+//   - keys are sorted within blocks of declarations delimited by comments or blank lines,
+//     unless this changes a value (because of references to other keys or duplicate keys)
+//   - comments are unindented, runs of blank lines are collapsed into one,
+//     blank lines at the start and end of the file are removed and lines end with LF
+//
+// Format never changes the parsed values: it returns an error if the result does not read the same as src.
+func Format(src []byte) ([]byte, error) {
+	want, err := UnmarshalBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, n := range doc.nodes {
+		if n.key == "" {
+			n.prefix = formatTrivia(n.prefix, i == 0, i == len(doc.nodes)-1)
+			continue
+		}
+
+		prefix := n.key + "="
+		if n.hasExport() {
+			prefix = exportPrefix + " " + prefix
+		}
+		n.prefix = prefix
+		n.value = canonicalValue(n.value)
+		n.suffix = formatSuffix(n.suffix)
+	}
+
+	doc.sortBlocks(want)
+
+	out := doc.String()
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+
+	if got, err := Unmarshal(out); err != nil || !reflect.DeepEqual(got, want) {
+		return nil, errors.New("formatting would change the values of the file")
+	}
+
+	return []byte(out), nil
+}
+
+// formatTrivia unindents comments, removes trailing whitespace and collapses blank lines
+// in lines without declarations.
+// Blank lines are dropped entirely at the start (first) and at the end (last) of the file.
+func formatTrivia(text string, first, last bool) string {
+	var b strings.Builder
+	blank := first
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+
+		b.WriteString(line + "\n")
+	}
+
+	out := b.String()
+	if last {
+		out = strings.TrimRight(out, "\n")
+		if out != "" {
+			out += "\n"
+		}
+	}
+
+	return out
+}
+
+// formatSuffix normalizes the spacing before an inline comment.
+// Suffixes holding anything else (another declaration on the same line) are kept as is.
+func formatSuffix(suffix string) string {
+	rest := strings.TrimFunc(suffix, unicode.IsSpace)
+	switch {
+	case rest == "":
+		return "\n"
+	case rest[0] == charComment:
+		return " " + rest + "\n"
+	default:
+		return suffix
+	}
+}
+
+// canonicalValue returns the raw value in canonical quote style, reading the same as raw.
+func canonicalValue(raw string) string {
+	if quote, ok := hasQuotePrefix([]byte(raw)); ok {
+		inner := raw[1 : len(raw)-1]
+		if isPlainValue(inner) && raw[len(raw)-1] == quote {
+			return inner
+		}
+
+		return raw
+	}
+
+	if strings.IndexFunc(raw, isSpace) != -1 && !strings.ContainsAny(raw, `\"`) {
+		return `"` + raw + `"`
+	}
+
+	return raw
+}
+
+// isPlainValue tells whether the value reads the same quoted or not:
+// it has no spaces, quotes, escapes, variable references or comment characters.
+func isPlainValue(value string) bool {
+	for _, r := range value {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-./:@%+,=~^*?&", r) {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// sortBlocks sorts the declarations by key within each run of consecutive declarations,
+// leaving the order of a run unchanged if sorting it changes the values read from the document.
+// A declaration annotated as sensitive stays at the start of its run, below the annotation.
+func (doc *Document) sortBlocks(want map[string]string) {
+	for start := 0; start < len(doc.nodes); start++ {
+		if doc.nodes[start].key == "" {
+			continue
+		}
+
+		end := start
+		for end < len(doc.nodes) && doc.nodes[end].key != "" {
+			end++
+		}
+
+		from := start
+		if start > 0 && isAnnotatedSensitive([]byte(doc.nodes[start-1].prefix), true) {
+			from++
+		}
+
+		block := doc.nodes[from:end]
+		original := append([]*node(nil), block...)
+		sort.SliceStable(block, func(i, j int) bool {
+			return block[i].key < block[j].key
+		})
+
+		if got, err := doc.Map(); err != nil || !reflect.DeepEqual(got, want) {
+			copy(block, original)
+		}
+
+		start = end
+	}
+}
@@ -0,0 +1,50 @@
+package env
+
+import "io/fs"
+
+// ReadFS works like Read, reading the files from fsys,
+// e.g. defaults embedded in the binary with go:embed.
+func ReadFS(fsys fs.FS, filenames ...string) (map[string]string, error) {
+	return NewLoader().ReadFS(fsys, filenames...)
+}
+
+// LoadFS works like Load, reading the files from fsys.
+func LoadFS(fsys fs.FS, filenames ...string) error {
+	return NewLoader().LoadFS(fsys, filenames...)
+}
+
+// OverloadFS works like Overload, reading the files from fsys.
+func OverloadFS(fsys fs.FS, filenames ...string) error {
+	return NewLoader().OverloadFS(fsys, filenames...)
+}
+
+// ReadFS works like the package-level ReadFS.
+// Search is ignored, and files named by X_FILE keys are still read from the operating system.
+func (l *Loader) ReadFS(fsys fs.FS, filenames ...string) (map[string]string, error) {
+	return l.withFS(fsys).Read(filenames...)
+}
+
+// LoadFS works like the package-level LoadFS.
+func (l *Loader) LoadFS(fsys fs.FS, filenames ...string) error {
+	return l.withFS(fsys).Load(filenames...)
+}
+
+// OverloadFS works like the package-level OverloadFS.
+func (l *Loader) OverloadFS(fsys fs.FS, filenames ...string) error {
+	return l.withFS(fsys).Overload(filenames...)
+}
+
+// withFS returns a copy of the loader reading the files from fsys.
+func (l *Loader) withFS(fsys fs.FS) *Loader {
+	return &Loader{
+		FileSuffix:  l.FileSuffix,
+		Clean:       l.Clean,
+		Keep:        l.Keep,
+		Search:      l.Search,
+		Prefix:      l.Prefix,
+		StripPrefix: l.StripPrefix,
+		Timeout:     l.Timeout,
+		resolvers:   l.resolvers,
+		fsys:        fsys,
+	}
+}
@@ -0,0 +1,640 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ImportFormats are the formats understood by Import.
+var ImportFormats = []string{"json", "yaml", "toml", "properties", "docker"}
+
+// Import reads configuration in another format and converts it into env variables.
+// The format is one of ImportFormats:
+//
+//   - json: an object, possibly nested
+//   - yaml: a map, possibly nested by indentation (lists, anchors and multi-line scalars are not supported)
+//   - toml: tables and key/value pairs with scalar or single-line array values
+//   - properties: Java .properties files, where dots in keys separate nested keys
+//   - docker: files for docker run --env-file, whose keys are kept as is
+//
+// Nested keys are flattened by joining them with separator (e.g. "__" turns db.host into DB__HOST).
+// Keys are uppercased and characters that are not letters, digits or underscores are replaced with underscores.
+// Array elements are flattened using their index as a key.
+// Import returns an error if different keys are flattened into the same variable (e.g. db-host and db_host).
+func Import(r io.Reader, format, separator string) (map[string]string, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := flattener{separator: separator, out: make(map[string]string), paths: make(map[string][]string)}
+	switch format {
+	case "json":
+		err = f.importJSON(src)
+	case "yaml":
+		err = f.importYAML(src)
+	case "toml":
+		err = f.importTOML(src)
+	case "properties":
+		err = f.importProperties(src)
+	case "docker":
+		err = importDocker(src, f.out)
+	default:
+		err = fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f.out, nil
+}
+
+// ImportFile reads the file with Import.
+// If format is empty, it is guessed from the extension of the file with ImportFormatOf.
+func ImportFile(filename, format, separator string) (map[string]string, error) {
+	if format == "" {
+		format = ImportFormatOf(filename)
+		if format == "" {
+			return nil, fmt.Errorf("cannot guess the format of %s", filename)
+		}
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Import(file, format, separator)
+}
+
+// ImportFormatOf returns the import format matching the extension of the file,
+// or an empty string if it is not known.
+func ImportFormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	case ".properties":
+		return "properties"
+	case ".list", ".env":
+		return "docker"
+	default:
+		return ""
+	}
+}
+
+// flattener converts nested configuration into env variables.
+type flattener struct {
+	separator string
+	out       map[string]string
+	// paths maps the env variables to the path of nested keys they were set from
+	paths map[string][]string
+}
+
+// key returns the env variable name for the path of nested keys.
+func (f flattener) key(path []string) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToUpper(r)
+			}
+			return '_'
+		}, p)
+	}
+
+	return strings.Join(parts, f.separator)
+}
+
+// set stores the value found at the path of nested keys.
+// It returns an error if another path was flattened into the same env variable,
+// a path set again replaces the value.
+func (f flattener) set(path []string, value string) error {
+	key := f.key(path)
+	if other, ok := f.paths[key]; ok && !reflect.DeepEqual(other, path) {
+		return fmt.Errorf("%s and %s are both imported as %s", strings.Join(other, "."), strings.Join(path, "."), key)
+	}
+
+	f.out[key], f.paths[key] = value, append([]string(nil), path...)
+	return nil
+}
+
+// walk stores the value found at the path of nested keys.
+// Keys of maps are walked in sorted order, so that errors do not depend on the order of maps.
+func (f flattener) walk(path []string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && len(path) > 0 {
+			return f.set(path, "")
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if err := f.walk(append(path[:len(path):len(path)], k), v[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if err := f.walk(append(path[:len(path):len(path)], strconv.Itoa(i)), child); err != nil {
+				return err
+			}
+		}
+	case string:
+		return f.set(path, v)
+	case json.Number:
+		return f.set(path, v.String())
+	case bool:
+		return f.set(path, strconv.FormatBool(v))
+	case nil:
+		return f.set(path, "")
+	default:
+		return fmt.Errorf("unsupported value of %s: %v", f.key(path), v)
+	}
+
+	return nil
+}
+
+func (f flattener) importJSON(src []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.UseNumber()
+
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return err
+	}
+
+	return f.walk(nil, root)
+}
+
+// yamlMap is a YAML map being parsed and the indentation of its keys.
+type yamlMap struct {
+	indent int
+	m      map[string]interface{}
+}
+
+func (f flattener) importYAML(src []byte) error {
+	root := make(map[string]interface{})
+	stack := []yamlMap{{indent: -1, m: root}}
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content[0] == '#' || content == "---" || content == "..." {
+			continue
+		}
+
+		if content[0] == '\t' {
+			return fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+
+		if content[0] == '-' && (len(content) == 1 || content[1] == ' ') {
+			return fmt.Errorf("line %d: lists are not supported", i+1)
+		}
+
+		key, rest, err := splitYAMLKey(content)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		indent := len(line) - len(content)
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].m
+
+		if rest == "" {
+			child := make(map[string]interface{})
+			parent[key] = child
+			stack = append(stack, yamlMap{indent: indent, m: child})
+			continue
+		}
+
+		if parent[key], err = parseYAMLScalar(rest); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	return f.walk(nil, root)
+}
+
+// splitYAMLKey splits "key: value" into the key and the rest of the line,
+// which is empty for keys of nested maps.
+func splitYAMLKey(s string) (key, rest string, err error) {
+	if s[0] == '"' || s[0] == '\'' {
+		value, n, err := unquoteYAML(s)
+		if err != nil {
+			return "", "", err
+		}
+
+		rest = strings.TrimLeft(s[n:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", errors.New("expected ':' after the key")
+		}
+
+		return value, stripYAMLComment(strings.TrimLeft(rest[1:], " ")), nil
+	}
+
+	i := strings.Index(s, ": ")
+	switch {
+	case i != -1:
+		return strings.TrimSpace(s[:i]), stripYAMLComment(strings.TrimLeft(s[i+2:], " ")), nil
+	case strings.HasSuffix(s, ":"):
+		return strings.TrimSpace(s[:len(s)-1]), "", nil
+	default:
+		return "", "", fmt.Errorf("expected 'key: value', got %q", s)
+	}
+}
+
+// stripYAMLComment removes a comment following a plain value.
+func stripYAMLComment(s string) string {
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+
+	if i := strings.Index(s, " #"); i != -1 && s[0] != '"' && s[0] != '\'' {
+		return strings.TrimRight(s[:i], " ")
+	}
+
+	return s
+}
+
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch s[0] {
+	case '"', '\'':
+		value, n, err := unquoteYAML(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if rest := strings.TrimLeft(s[n:], " "); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+
+		return value, nil
+	case '[', '{', '&', '*', '|', '>', '!':
+		return nil, fmt.Errorf("unsupported value %q", s)
+	}
+
+	if s == "~" || s == "null" {
+		return nil, nil
+	}
+
+	return s, nil
+}
+
+// unquoteYAML parses the quoted scalar at the start of s, returning its value and length.
+func unquoteYAML(s string) (value string, n int, err error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '\'' && s[i] == '\'':
+			if i+1 < len(s) && s[i+1] == '\'' {
+				// '' is an escaped single quote
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'"), i + 1, nil
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '"' && s[i] == '"':
+			value, err = strconv.Unquote(s[:i+1])
+			return value, i + 1, err
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated quoted value %s", s)
+}
+
+func (f flattener) importTOML(src []byte) error {
+	root := make(map[string]interface{})
+	table := root
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var err error
+		if line[0] == '[' {
+			table, err = tomlTable(root, line)
+		} else {
+			err = tomlKeyValue(table, line)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	return f.walk(nil, root)
+}
+
+// tomlTable returns the table named by the header line, creating it if needed.
+func tomlTable(root map[string]interface{}, line string) (map[string]interface{}, error) {
+	if strings.HasPrefix(line, "[[") {
+		return nil, errors.New("arrays of tables are not supported")
+	}
+
+	path, rest, err := parseTOMLKey(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(rest, "]") || !isTOMLLineEnd(rest[1:]) {
+		return nil, fmt.Errorf("malformed table header %q", line)
+	}
+
+	return tomlSubtable(root, path)
+}
+
+func tomlSubtable(table map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, name := range path {
+		switch child := table[name].(type) {
+		case nil:
+			sub := make(map[string]interface{})
+			table[name] = sub
+			table = sub
+		case map[string]interface{}:
+			table = child
+		default:
+			return nil, fmt.Errorf("%s is not a table", name)
+		}
+	}
+
+	return table, nil
+}
+
+func tomlKeyValue(table map[string]interface{}, line string) error {
+	path, rest, err := parseTOMLKey(line)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(rest, "=") {
+		return fmt.Errorf("expected 'key = value', got %q", line)
+	}
+
+	value, rest, err := parseTOMLValue(strings.TrimLeft(rest[1:], " \t"))
+	if err != nil {
+		return err
+	}
+
+	if !isTOMLLineEnd(rest) {
+		return fmt.Errorf("unexpected %q after value", rest)
+	}
+
+	parent, err := tomlSubtable(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	parent[path[len(path)-1]] = value
+	return nil
+}
+
+// parseTOMLKey parses a possibly dotted and quoted key at the start of s.
+func parseTOMLKey(s string) (path []string, rest string, err error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+			var value interface{}
+			if value, s, err = parseTOMLValue(s); err != nil {
+				return nil, "", err
+			}
+			part = value.(string)
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r))
+			})
+			if end == -1 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, "", fmt.Errorf("expected a key, got %q", s)
+			}
+			part, s = s[:end], s[end:]
+		}
+
+		path = append(path, part)
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return path, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// parseTOMLValue parses the value at the start of s.
+func parseTOMLValue(s string) (value interface{}, rest string, err error) {
+	switch {
+	case s == "":
+		return nil, "", errors.New("missing value")
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return nil, "", errors.New("multi-line strings are not supported")
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case s[0] == '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				str, err := strconv.Unquote(s[:i+1])
+				return str, s[i+1:], err
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated string %s", s)
+	case s[0] == '[':
+		return parseTOMLArray(s)
+	case s[0] == '{':
+		return nil, "", errors.New("inline tables are not supported")
+	}
+
+	// booleans, numbers and dates are kept as written
+	end := strings.IndexAny(s, ",]#")
+	if end == -1 {
+		end = len(s)
+	}
+
+	token := strings.TrimSpace(s[:end])
+	if token == "" {
+		return nil, "", fmt.Errorf("missing value before %q", s)
+	}
+
+	return token, s[end:], nil
+}
+
+func parseTOMLArray(s string) (value interface{}, rest string, err error) {
+	var values []interface{}
+	s = strings.TrimLeft(s[1:], " \t")
+	for !strings.HasPrefix(s, "]") {
+		var v interface{}
+		if v, s, err = parseTOMLValue(s); err != nil {
+			return nil, "", err
+		}
+		values = append(values, v)
+
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimLeft(s[1:], " \t")
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("unterminated array (multi-line arrays are not supported)")
+		}
+	}
+
+	return values, s[1:], nil
+}
+
+func isTOMLLineEnd(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+func (f flattener) importProperties(src []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// an odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, `\`))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value := splitProperty(logical.String())
+		logical.Reset()
+		if err := f.set(strings.Split(key, "."), value); err != nil {
+			return err
+		}
+	}
+
+	if logical.Len() > 0 {
+		key, value := splitProperty(logical.String())
+		if err := f.set(strings.Split(key, "."), value); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// splitProperty splits a logical line of a .properties file into its unescaped key and value.
+func splitProperty(line string) (key, value string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if line[i] == '=' || line[i] == ':' || line[i] == ' ' || line[i] == '\t' || line[i] == '\f' {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	return unescapeProperty(line[:end]), unescapeProperty(rest)
+}
+
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 <= len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// importDocker reads a file for docker run --env-file:
+// KEY=VALUE lines taken literally, comments and, like docker,
+// keys without a value taken from the environment if they are set.
+func importDocker(src []byte, out map[string]string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("line %d: invalid variable name %q", n, key)
+		}
+
+		if !ok {
+			if value, ok = os.LookupEnv(key); !ok {
+				continue
+			}
+		}
+
+		out[key] = value
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,154 @@
+// Package autoenv implements the autoload packages,
+// which load env files when they are imported, configured by environment variables.
+package autoenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/env"
+)
+
+// Environment variables configuring autoloading.
+const (
+	// FilesEnv is a comma separated list of the files to load (.env by default).
+	FilesEnv = "ENV_FILES"
+	// RequiredEnv makes any error, including a missing file, panic.
+	RequiredEnv = "ENV_REQUIRED"
+	// OverloadEnv makes the files override existing variables, like env.Overload.
+	OverloadEnv = "ENV_OVERLOAD"
+	// CascadeEnv names the variable holding the name of the environment (e.g. APP_ENV=production),
+	// which makes every file FILE cascade into FILE.NAME.local, FILE.local (except for test), FILE.NAME and FILE,
+	// in order of precedence.
+	CascadeEnv = "ENV_CASCADE"
+)
+
+// config is the configuration of autoloading.
+type config struct {
+	files    []string
+	required bool
+	overload bool
+	// environment is the name of the environment read from the variable named by CascadeEnv
+	environment string
+	cascade     bool
+}
+
+// file is a file to load.
+type file struct {
+	name string
+	// optional files are skipped if they do not exist
+	optional bool
+}
+
+// Run loads the files configured by the environment variables, returning the paths of the files loaded.
+// Relative files are searched for in the current directory and its parents, like env.Find.
+// Overload and must force overloading and panicking on errors, whatever the configuration.
+// Otherwise, missing files are skipped and errors are reported on the standard error.
+func Run(overload, must bool) []string {
+	c := readConfig(os.Getenv)
+	c.overload = c.overload || overload
+	c.required = c.required || must
+
+	loaded, err := c.load()
+	if err != nil {
+		if c.required {
+			panic(fmt.Sprintf("autoload: %v", err))
+		}
+
+		fmt.Fprintf(os.Stderr, "autoload: %v\n", err)
+	}
+
+	return loaded
+}
+
+func readConfig(getenv func(string) string) config {
+	c := config{files: []string{".env"}}
+	if raw := getenv(FilesEnv); raw != "" {
+		c.files = nil
+		for _, name := range strings.Split(raw, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.files = append(c.files, name)
+			}
+		}
+	}
+
+	c.required, _ = strconv.ParseBool(getenv(RequiredEnv))
+	c.overload, _ = strconv.ParseBool(getenv(OverloadEnv))
+	if name := getenv(CascadeEnv); name != "" {
+		c.cascade = true
+		c.environment = getenv(name)
+	}
+
+	return c
+}
+
+// filenames returns the files to load, in the order they must be loaded.
+func (c config) filenames() []file {
+	var files []file
+	for _, name := range c.files {
+		if !c.cascade {
+			files = append(files, file{name: name, optional: !c.required})
+			continue
+		}
+
+		if c.environment != "" {
+			files = append(files, file{name: name + "." + c.environment + ".local", optional: true})
+		}
+		if c.environment != "test" {
+			files = append(files, file{name: name + ".local", optional: true})
+		}
+		if c.environment != "" {
+			files = append(files, file{name: name + "." + c.environment, optional: true})
+		}
+		files = append(files, file{name: name, optional: !c.required})
+	}
+
+	// the first file to load takes precedence with Load, the last one with Overload
+	if c.overload {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+
+	return files
+}
+
+// load loads the files, returning the paths of the files loaded.
+func (c config) load() ([]string, error) {
+	load := env.Load
+	if c.overload {
+		load = env.Overload
+	}
+
+	var loaded []string
+	for _, f := range c.filenames() {
+		path := f.name
+		if found, err := env.Find(f.name); err == nil {
+			path = found
+		}
+
+		err := load(path)
+		if err == nil {
+			loaded = append(loaded, path)
+			continue
+		}
+
+		if f.optional && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		// errors opening the file already name it
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return loaded, err
+		}
+
+		return loaded, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,72 @@
+package autoenv
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilenames(t *testing.T) {
+	tests := map[string]struct {
+		vars     map[string]string
+		expected []file
+	}{
+		"default": {
+			expected: []file{{name: ".env", optional: true}},
+		},
+		"files": {
+			vars:     map[string]string{FilesEnv: "a.env, b.env", RequiredEnv: "1"},
+			expected: []file{{name: "a.env"}, {name: "b.env"}},
+		},
+		"cascade": {
+			vars: map[string]string{CascadeEnv: "APP_ENV", "APP_ENV": "production"},
+			expected: []file{
+				{name: ".env.production.local", optional: true},
+				{name: ".env.local", optional: true},
+				{name: ".env.production", optional: true},
+				{name: ".env", optional: true},
+			},
+		},
+		"cascade test overload": {
+			vars: map[string]string{CascadeEnv: "APP_ENV", "APP_ENV": "test", OverloadEnv: "true"},
+			expected: []file{
+				{name: ".env", optional: true},
+				{name: ".env.test", optional: true},
+				{name: ".env.test.local", optional: true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := readConfig(func(key string) string { return test.vars[key] })
+			if files := c.filenames(); !reflect.DeepEqual(files, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, files)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/.env", []byte("AUTOENV_A=1\nAUTOENV_B=x y z\n$\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config{files: []string{dir + "/.env"}}
+	_, err := c.load()
+	if err == nil || !strings.Contains(err.Error(), "/.env: line 3:") {
+		t.Errorf("Expected an error naming the file and line, got %v", err)
+	}
+
+	c = config{files: []string{dir + "/missing.env"}}
+	if _, err = c.load(); err != nil {
+		t.Errorf("Expected a missing file to be skipped, got %v", err)
+	}
+
+	c.required = true
+	if _, err = c.load(); err == nil {
+		t.Error("Expected an error loading a missing required file")
+	}
+}
@@ -0,0 +1,26 @@
+// Package envkv handles the "key=value" entries of process environments.
+package envkv
+
+import "strings"
+
+// Split returns the key and value of a "key=value" environment entry,
+// and whether the entry has a separator.
+// The search for the separator starts at the second character,
+// because Windows has entries such as "=C:=C:\".
+func Split(kv string) (key, value string, ok bool) {
+	if len(kv) == 0 {
+		return "", "", false
+	}
+
+	if i := strings.IndexByte(kv[1:], '='); i != -1 {
+		return kv[:i+1], kv[i+2:], true
+	}
+
+	return kv, "", false
+}
+
+// Key returns the key of a "key=value" environment entry, the whole entry if it has no separator.
+func Key(kv string) string {
+	key, _, _ := Split(kv)
+	return key
+}
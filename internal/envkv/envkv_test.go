@@ -0,0 +1,23 @@
+package envkv
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		kv, key, value string
+		ok             bool
+	}{
+		{"A=1", "A", "1", true},
+		{"A=1=2", "A", "1=2", true},
+		{"=C:=C:\\", "=C:", "C:\\", true},
+		{"A", "A", "", false},
+		{"", "", "", false},
+	}
+
+	for _, test := range tests {
+		key, value, ok := Split(test.kv)
+		if key != test.key || value != test.value || ok != test.ok {
+			t.Errorf("Split(%q) = %q, %q, %v, expected %q, %q, %v", test.kv, key, value, ok, test.key, test.value, test.ok)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Rules reported by Lint.
+const (
+	RuleDuplicateKey       = "duplicate-key"
+	RuleLowercaseKey       = "lowercase-key"
+	RuleUnquotedSpace      = "unquoted-space"
+	RuleTrailingWhitespace = "trailing-whitespace"
+	RuleUndefinedVariable  = "undefined-variable"
+	RuleExport             = "export"
+	RuleCRLF               = "crlf"
+	RuleFinalNewline       = "final-newline"
+)
+
+// Issue is a style or correctness problem found by Lint.
+type Issue struct {
+	// Line is the 1-based line number of the problem.
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Fixable is true if Fix corrects the problem.
+	Fixable bool `json:"fixable"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d: %s: %s", i.Line, i.Rule, i.Message)
+}
+
+// Lint reports the problems found in the source of an env file, sorted by line.
+// It returns an error if the source cannot be parsed.
+func Lint(src []byte) ([]Issue, error) {
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if i := bytes.Index(src, []byte("\r\n")); i != -1 {
+		issues = append(issues, Issue{
+			Line:    bytes.Count(src[:i], []byte("\n")) + 1,
+			Rule:    RuleCRLF,
+			Message: "file has CRLF line endings",
+			Fixable: true,
+		})
+	}
+
+	// duplicates kept by Fix because a value refers to them
+	var kept map[int]bool
+	if fixed, err := ParseDocument(src); err == nil {
+		kept = fixed.dropDuplicates()
+	}
+
+	defined := make(map[string]int)
+	keptKeys := make(map[string]bool)
+	index := -1
+	doc.walk(func(n *node, line int) {
+		index++
+		if n.key == "" {
+			for i, l := range strings.SplitAfter(n.prefix, "\n") {
+				if hasTrailingWhitespace(l) {
+					issues = append(issues, trailingWhitespace(line+i))
+				}
+			}
+			return
+		}
+
+		issues = append(issues, lintDeclaration(n, line, defined, !keptKeys[n.key])...)
+		// the suffix starts on the last line of a multi-line value,
+		// spaces before another declaration on the same line are not trailing
+		if hasTrailingWhitespace(n.suffix) && !doc.joined(index) {
+			issues = append(issues, trailingWhitespace(line+strings.Count(n.prefix+n.value, "\n")))
+		}
+
+		defined[n.key] = line
+		keptKeys[n.key] = keptKeys[n.key] || kept[index]
+	})
+
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		issues = append(issues, Issue{
+			Line:    bytes.Count(src, []byte("\n")) + 1,
+			Rule:    RuleFinalNewline,
+			Message: "file does not end with a line break",
+			Fixable: true,
+		})
+	}
+
+	sortIssues(issues)
+	return issues, nil
+}
+
+// lintDeclaration reports the problems of a declaration.
+// A duplicate key is fixable unless a previous definition must be kept because a value refers to it.
+func lintDeclaration(n *node, line int, defined map[string]int, fixableDuplicate bool) (issues []Issue) {
+	if first, ok := defined[n.key]; ok {
+		issues = append(issues, Issue{
+			Line:    line,
+			Rule:    RuleDuplicateKey,
+			Message: fmt.Sprintf("%s is already defined on line %d", n.key, first),
+			Fixable: fixableDuplicate,
+		})
+	}
+
+	if strings.IndexFunc(n.key, unicode.IsLower) != -1 {
+		issues = append(issues, Issue{
+			Line:    line,
+			Rule:    RuleLowercaseKey,
+			Message: fmt.Sprintf("%s contains lowercase letters", n.key),
+		})
+	}
+
+	if n.hasExport() {
+		issues = append(issues, Issue{
+			Line:    line,
+			Rule:    RuleExport,
+			Message: fmt.Sprintf("export before %s has no effect", n.key),
+			Fixable: true,
+		})
+	}
+
+	quote, quoted := hasQuotePrefix([]byte(n.value))
+	if !quoted && strings.IndexFunc(n.value, isSpace) != -1 {
+		issues = append(issues, Issue{
+			Line:    line,
+			Rule:    RuleUnquotedSpace,
+			Message: fmt.Sprintf("value of %s contains spaces and should be quoted", n.key),
+			Fixable: !strings.ContainsAny(n.value, `\"`),
+		})
+	}
+
+	if quote != prefixSingleQuote {
+		for _, submatch := range expandVarRegex.FindAllStringSubmatch(n.value, -1) {
+			name := submatch[4]
+			if _, ok := defined[name]; !ok && submatch[1] == "" && name != "" {
+				issues = append(issues, Issue{
+					Line:    line,
+					Rule:    RuleUndefinedVariable,
+					Message: fmt.Sprintf("value of %s refers to %s, which is not defined before", n.key, name),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+func trailingWhitespace(line int) Issue {
+	return Issue{Line: line, Rule: RuleTrailingWhitespace, Message: "line ends with whitespace", Fixable: true}
+}
+
+func hasTrailingWhitespace(line string) bool {
+	line = strings.TrimSuffix(line, "\n")
+	return line != strings.TrimRightFunc(line, isSpace)
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+}
+
+// Fix returns the source of an env file with the fixable problems reported by Lint corrected:
+// line endings are converted to LF, trailing whitespace and export are removed,
+// unquoted values with spaces are quoted, only the last definition of duplicate keys is kept
+// (unless a value refers to a previous one) and a final line break is added.
+// Values are left unchanged: Fix returns an error if the result does not read the same as src.
+func Fix(src []byte) ([]byte, error) {
+	want, err := UnmarshalBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.dropDuplicates()
+	for i, n := range doc.nodes {
+		if n.key != "" {
+			n.fix(doc.joined(i))
+			continue
+		}
+
+		lines := strings.SplitAfter(n.prefix, "\n")
+		for i, l := range lines {
+			lines[i] = trimTrailingWhitespace(l)
+		}
+		n.prefix = strings.Join(lines, "")
+	}
+
+	out := doc.String()
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+
+	if got, err := Unmarshal(out); err != nil || !reflect.DeepEqual(got, want) {
+		return nil, errors.New("fixing would change the values of the file")
+	}
+
+	return []byte(out), nil
+}
+
+// dropDuplicates removes the declarations overridden by a later declaration of the same key,
+// except those whose removal changes the values read from the document
+// because a value refers to them.
+// It returns the indexes (in the original document) of the overridden declarations kept.
+func (doc *Document) dropDuplicates() map[int]bool {
+	want, err := doc.Map()
+	if err != nil {
+		return nil
+	}
+
+	last := make(map[string]int)
+	for i, n := range doc.nodes {
+		if n.key != "" {
+			last[n.key] = i
+		}
+	}
+
+	original := doc.nodes
+	removed := make(map[int]bool)
+	kept := make(map[int]bool)
+	for i, n := range original {
+		if n.key == "" || last[n.key] == i {
+			continue
+		}
+
+		removed[i] = true
+		doc.nodes = withoutNodes(original, removed)
+		if got, err := doc.Map(); err != nil || !reflect.DeepEqual(got, want) {
+			delete(removed, i)
+			kept[i] = true
+		}
+	}
+
+	doc.nodes = withoutNodes(original, removed)
+	return kept
+}
+
+// fix corrects the fixable problems of a declaration,
+// keeping the spaces before the next declaration if it is joined to it on the same line.
+func (n *node) fix(joined bool) {
+	if n.hasExport() {
+		indent := n.prefix[:len(n.prefix)-len(strings.TrimLeftFunc(n.prefix, isSpace))]
+		n.prefix = indent + strings.TrimLeftFunc(strings.TrimLeftFunc(n.prefix, isSpace)[len(exportPrefix):], isSpace)
+	}
+
+	if _, quoted := hasQuotePrefix([]byte(n.value)); !quoted &&
+		strings.IndexFunc(n.value, isSpace) != -1 && !strings.ContainsAny(n.value, `\"`) {
+		n.value = `"` + n.value + `"`
+	}
+
+	if !joined {
+		n.suffix = trimTrailingWhitespace(n.suffix)
+	}
+}
+
+// hasExport tells whether the declaration starts with the export keyword.
+func (n *node) hasExport() bool {
+	prefix := strings.TrimLeftFunc(n.prefix, isSpace)
+	return strings.HasPrefix(prefix, exportPrefix) &&
+		strings.IndexFunc(prefix[len(exportPrefix):], isSpace) == 0
+}
+
+func trimTrailingWhitespace(line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return strings.TrimRightFunc(line[:len(line)-1], isSpace) + "\n"
+	}
+
+	return strings.TrimRightFunc(line, isSpace)
+}
@@ -0,0 +1,602 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pchchv/env/internal/envkv"
+)
+
+const fileSuffix = "_FILE"
+
+// Loader reads env files like the package-level functions,
+// with additional processing of values configured on it.
+// The zero value is ready to use and behaves exactly like the package-level functions.
+type Loader struct {
+	// FileSuffix enables the convention of the official Docker images:
+	// for every X_FILE key the file it names is read and X is set to its trimmed contents.
+	// It is an error to set both X and X_FILE, whether in one file or in different files,
+	// or to set X_FILE in a file when X is already in the environment (for Load, Overload and Environ).
+	FileSuffix bool
+
+	// Clean makes Environ, Command and ExecWithEnv start from an empty environment,
+	// like env -i, keeping from the base environment only the variables listed in Keep.
+	Clean bool
+	// Keep lists the variables kept from the base environment when Clean is set, e.g. PATH, HOME and TERM.
+	Keep []string
+
+	// Search makes relative filenames, including the default .env, searched for like Find
+	// in the current directory and its parents.
+	// The provenance of the values names the files found.
+	Search bool
+
+	// Prefix makes only the keys starting with it read from the files, e.g. BILLING_
+	// for the keys of one of the services sharing a file.
+	// Values can still refer to other keys.
+	Prefix string
+	// StripPrefix removes Prefix from the keys read, so that BILLING_PORT is read as PORT.
+	StripPrefix bool
+
+	// Timeout is the timeout of the requests for files named by HTTP URLs, DefaultTimeout if zero.
+	Timeout time.Duration
+
+	resolvers map[string]SecretResolver
+	// fsys is the filesystem the files are read from, the operating system's if nil
+	fsys fs.FS
+
+	mu sync.Mutex
+	// parsed holds the sources of the files read, by name
+	parsed map[string]Source
+}
+
+// NewLoader returns a Loader without any additional processing.
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// Register makes values referencing the scheme (e.g. "file" for file:///run/secrets/db)
+// resolved by the resolver when they are read.
+func (l *Loader) Register(scheme string, resolver SecretResolver) {
+	if l.resolvers == nil {
+		l.resolvers = make(map[string]SecretResolver)
+	}
+
+	l.resolvers[scheme] = resolver
+}
+
+// Read works like the package-level Read.
+func (l *Loader) Read(filenames ...string) (envMap map[string]string, err error) {
+	envMap, _, err = l.ReadWithProvenance(filenames...)
+	return
+}
+
+// Load works like the package-level Load.
+func (l *Loader) Load(filenames ...string) error {
+	_, err := l.loadFiles(filenames, false, nil)
+	return err
+}
+
+// Overload works like the package-level Overload.
+func (l *Loader) Overload(filenames ...string) error {
+	_, err := l.loadFiles(filenames, true, nil)
+	return err
+}
+
+// ReadWithProvenance works like the package-level ReadWithProvenance.
+func (l *Loader) ReadWithProvenance(filenames ...string) (map[string]string, Provenance, error) {
+	sources, err := l.sources(filenames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return l.readSources(sources)
+}
+
+// ReadSources works like the package-level ReadSources.
+func (l *Loader) ReadSources(sources ...Source) (map[string]string, error) {
+	envMap, _, err := l.readSources(sources)
+	return envMap, err
+}
+
+// LoadSources works like the package-level LoadSources.
+func (l *Loader) LoadSources(sources ...Source) error {
+	_, err := l.loadSources(sources, false, nil)
+	return err
+}
+
+// OverloadSources works like the package-level OverloadSources.
+func (l *Loader) OverloadSources(sources ...Source) error {
+	_, err := l.loadSources(sources, true, nil)
+	return err
+}
+
+func (l *Loader) readSources(sources []Source) (envMap map[string]string, prov Provenance, err error) {
+	envMap = make(map[string]string)
+	prov = make(Provenance)
+	cache := make(map[string]string)
+	var check fileSuffixCheck
+
+	for _, source := range sources {
+		individualEnvMap, decls, individualErr := l.readSource(source, cache)
+		if individualErr == nil && l.FileSuffix {
+			individualErr = check.add(source.Name(), individualEnvMap)
+		}
+		if individualErr != nil {
+			err = individualErr
+			return
+		}
+
+		for key, value := range individualEnvMap {
+			status := StatusSet
+			if _, ok := envMap[key]; ok {
+				status = StatusOverridden
+			}
+
+			envMap[key] = value
+			prov.add(key, newOrigin(source.Name(), decls[key], status))
+		}
+	}
+
+	return
+}
+
+// LoadWithProvenance works like the package-level LoadWithProvenance.
+func (l *Loader) LoadWithProvenance(filenames ...string) (Provenance, error) {
+	return l.loadFiles(filenames, false, nil)
+}
+
+// OverloadWithProvenance works like the package-level OverloadWithProvenance.
+func (l *Loader) OverloadWithProvenance(filenames ...string) (Provenance, error) {
+	return l.loadFiles(filenames, true, nil)
+}
+
+// loadFiles sets the values read from the files in the environment,
+// recording the previous values in applied if it is not nil.
+func (l *Loader) loadFiles(filenames []string, overload bool, applied *Applied) (Provenance, error) {
+	sources, err := l.sources(filenames)
+	if err != nil {
+		return make(Provenance), err
+	}
+
+	return l.loadSources(sources, overload, applied)
+}
+
+func (l *Loader) loadSources(sources []Source, overload bool, applied *Applied) (Provenance, error) {
+	prov := make(Provenance)
+	cache := make(map[string]string)
+	var check fileSuffixCheck
+	for _, source := range sources {
+		if err := l.loadSource(source, overload, prov, cache, applied, &check); err != nil {
+			return prov, err
+		}
+	}
+
+	return prov, nil
+}
+
+func (l *Loader) loadSource(source Source, overload bool, prov Provenance, cache map[string]string, applied *Applied, check *fileSuffixCheck) error {
+	envMap, decls, err := l.readSource(source, cache)
+	if err != nil {
+		return err
+	}
+
+	if l.FileSuffix {
+		if err = check.add(source.Name(), envMap); err != nil {
+			return err
+		}
+
+		if err = checkFileSuffixEnv(source.Name(), envMap, os.LookupEnv); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range envMap {
+		origin := newOrigin(source.Name(), decls[key], StatusSet)
+		if _, ok := os.LookupEnv(key); ok {
+			origin.Status = StatusSkipped
+			if overload {
+				origin.Status = StatusOverridden
+			}
+		}
+
+		if origin.Status != StatusSkipped {
+			_ = applied.setenv(key, value)
+		}
+		prov.add(key, origin)
+	}
+
+	return nil
+}
+
+// readSource parses the source, additionally returning the declaration that defined each key.
+// Encrypted values are decrypted with the key returned by ReadKey,
+// then secret references are resolved, sharing the results in cache.
+// The declarations of decrypted and resolved values are marked as sensitive,
+// as are those referencing sensitive keys.
+func (l *Loader) readSource(source Source, cache map[string]string) (envMap map[string]string, decls map[string]declaration, err error) {
+	src, err := source.Fetch()
+	if err != nil {
+		return
+	}
+
+	decrypt := decrypter()
+	envMap = make(map[string]string)
+	decls = make(map[string]declaration)
+	err = parseBytesFunc(src, envMap, func(d *declaration) (err error) {
+		if IsEncrypted(d.value) {
+			d.sensitive = true
+		}
+
+		for _, ref := range d.refs {
+			if decls[ref].sensitive {
+				d.sensitive = true
+			}
+		}
+
+		if d.value, err = decrypt(d.key, d.value); err != nil {
+			return err
+		}
+
+		if l.resolver(d.value) != nil {
+			d.sensitive = true
+		}
+
+		if d.value, err = l.resolve(d.key, d.value, cache); err != nil {
+			return err
+		}
+
+		decls[d.key] = *d
+		return nil
+	})
+	if err == nil && l.FileSuffix {
+		err = expandFileSuffix(envMap, decls)
+	}
+	if err == nil && l.Prefix != "" {
+		envMap, decls = l.filterPrefix(envMap, decls)
+	}
+
+	return
+}
+
+// filterPrefix keeps the keys starting with Prefix, stripping it if StripPrefix is set.
+func (l *Loader) filterPrefix(envMap map[string]string, decls map[string]declaration) (map[string]string, map[string]declaration) {
+	filteredMap := make(map[string]string)
+	filteredDecls := make(map[string]declaration)
+	for key, value := range envMap {
+		name, ok := strings.CutPrefix(key, l.Prefix)
+		if !l.StripPrefix {
+			name = key
+		}
+
+		// stripping the prefix from the key equal to it would leave no key
+		if !ok || name == "" {
+			continue
+		}
+
+		d := decls[key]
+		d.key = name
+		filteredMap[name], filteredDecls[name] = value, d
+	}
+
+	return filteredMap, filteredDecls
+}
+
+// expandFileSuffix sets X to the trimmed contents of the file named by X_FILE.
+func expandFileSuffix(envMap map[string]string, decls map[string]declaration) error {
+	var keys []string
+	for key := range envMap {
+		if _, ok := fileSuffixName(key); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		name, _ := fileSuffixName(key)
+		filename := envMap[key]
+		if _, ok := envMap[name]; ok {
+			return fmt.Errorf("both %s and %s are set", name, key)
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", key, err)
+		}
+
+		d := decls[key]
+		d.key, d.value = name, strings.TrimSpace(string(content))
+		envMap[name], decls[name] = d.value, d
+	}
+
+	return nil
+}
+
+// fileSuffixCheck detects keys set directly in one file and through X_FILE in another.
+type fileSuffixCheck struct {
+	// direct and viaFile map the keys set directly and through X_FILE to the name of their source
+	direct, viaFile map[string]string
+}
+
+// add records the keys read from the source, after expandFileSuffix,
+// returning an error if a key set directly was set through X_FILE by a previous source or the reverse.
+func (c *fileSuffixCheck) add(source string, envMap map[string]string) error {
+	if c.direct == nil {
+		c.direct = make(map[string]string)
+		c.viaFile = make(map[string]string)
+	}
+
+	for key := range envMap {
+		name, ok := fileSuffixName(key)
+		if !ok {
+			continue
+		}
+
+		if other, ok := c.direct[name]; ok {
+			return fmt.Errorf("both %s (in %s) and %s (in %s) are set", name, other, key, source)
+		}
+		c.viaFile[name] = source
+	}
+
+	for key := range envMap {
+		if _, ok := envMap[key+fileSuffix]; ok {
+			// set through X_FILE by this source
+			continue
+		}
+
+		if other, ok := c.viaFile[key]; ok {
+			return fmt.Errorf("both %s (in %s) and %s%s (in %s) are set", key, source, key, fileSuffix, other)
+		}
+		c.direct[key] = source
+	}
+
+	return nil
+}
+
+// checkFileSuffixEnv returns an error if a key set through X_FILE by the source is already set in the environment.
+// The key may be set in the environment along with X_FILE, as after loading the same file before.
+func checkFileSuffixEnv(source string, envMap map[string]string, lookup func(string) (string, bool)) error {
+	for key := range envMap {
+		name, ok := fileSuffixName(key)
+		if !ok {
+			continue
+		}
+
+		if _, ok := lookup(name); !ok {
+			continue
+		}
+
+		if _, ok := lookup(key); !ok {
+			return fmt.Errorf("both %s (in the environment) and %s (in %s) are set", name, key, source)
+		}
+	}
+
+	return nil
+}
+
+// fileSuffixName returns X for a key X_FILE.
+func fileSuffixName(key string) (string, bool) {
+	if !strings.HasSuffix(key, fileSuffix) || key == fileSuffix {
+		return "", false
+	}
+
+	return strings.TrimSuffix(key, fileSuffix), true
+}
+
+// resolver returns the resolver registered for the scheme the value starts with, or nil.
+func (l *Loader) resolver(value string) SecretResolver {
+	i := strings.Index(value, "://")
+	if i <= 0 {
+		return nil
+	}
+
+	return l.resolvers[value[:i]]
+}
+
+// resolve returns the secret the value refers to
+// if it starts with the scheme of a registered resolver.
+func (l *Loader) resolve(key, value string, cache map[string]string) (string, error) {
+	resolver := l.resolver(value)
+	if resolver == nil {
+		return value, nil
+	}
+
+	if secret, ok := cache[value]; ok {
+		return secret, nil
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret reference in %s: %w", key, err)
+	}
+
+	secret, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve secret reference in %s: %w", key, err)
+	}
+
+	cache[value] = secret
+	return secret, nil
+}
+
+// Environ returns the environment for a child process as a list of "key=value" strings:
+// base (the environment of the current process if nil) merged with the values read from the files,
+// following the same precedence as Load, or Overload if overload is true.
+// If Clean is set, only the variables listed in Keep are taken from base.
+// The environment of the current process is left untouched.
+func (l *Loader) Environ(base []string, filenames []string, overload bool) ([]string, error) {
+	if base == nil {
+		base = os.Environ()
+	}
+
+	environ := make([]string, 0, len(base))
+	for _, kv := range base {
+		if !l.Clean || l.keeps(envkv.Key(kv)) {
+			environ = append(environ, kv)
+		}
+	}
+
+	index := make(map[string]int, len(environ))
+	for i, kv := range environ {
+		index[envkv.Key(kv)] = i
+	}
+
+	sources, err := l.sources(filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := make(map[string]string)
+	var check fileSuffixCheck
+	for _, source := range sources {
+		envMap, _, err := l.readSource(source, cache)
+		if err != nil {
+			return nil, err
+		}
+
+		if l.FileSuffix {
+			if err = check.add(source.Name(), envMap); err != nil {
+				return nil, err
+			}
+
+			lookup := func(key string) (string, bool) {
+				if i, ok := index[key]; ok {
+					_, value, _ := envkv.Split(environ[i])
+					return value, true
+				}
+
+				return "", false
+			}
+			if err = checkFileSuffixEnv(source.Name(), envMap, lookup); err != nil {
+				return nil, err
+			}
+		}
+
+		keys := make([]string, 0, len(envMap))
+		for key := range envMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			kv := key + "=" + envMap[key]
+			if i, ok := index[key]; !ok {
+				index[key] = len(environ)
+				environ = append(environ, kv)
+			} else if overload {
+				environ[i] = kv
+			}
+		}
+	}
+
+	return environ, nil
+}
+
+// Command returns a command running name with args
+// in the environment built by Environ from the environment of the current process and the files.
+// Its standard input, output and error are those of the current process.
+// The returned command can be further customized before it is started,
+// and is killed if ctx is done before it exits.
+func (l *Loader) Command(ctx context.Context, filenames []string, overload bool, name string, args ...string) (*exec.Cmd, error) {
+	environ, err := l.Environ(nil, filenames, overload)
+	if err != nil {
+		return nil, err
+	}
+
+	command := exec.CommandContext(ctx, name, args...)
+	command.Env = environ
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command, nil
+}
+
+// ExecWithEnv works like the package-level ExecWithEnv.
+func (l *Loader) ExecWithEnv(filenames []string, cmd string, cmdArgs []string, overload bool) error {
+	command, err := l.Command(context.Background(), filenames, overload, cmd, cmdArgs...)
+	if err != nil {
+		return err
+	}
+
+	return Run(command)
+}
+
+// filenames returns the files to read (.env by default),
+// with the paths found by Find if Search is set.
+func (l *Loader) filenames(filenames []string) []string {
+	filenames = filenamesOrDefault(filenames)
+	if !l.Search || l.fsys != nil {
+		return filenames
+	}
+
+	found := make([]string, len(filenames))
+	for i, filename := range filenames {
+		found[i] = filename
+		if path, err := Find(filename); err == nil {
+			found[i] = path
+		}
+	}
+
+	return found
+}
+
+// sources returns the sources of the files (.env by default).
+// If the loader reads from a filesystem, the names are paths in it,
+// otherwise they are paths or URIs parsed by ParseSource.
+// The sources are kept, so that reading the same name again reuses
+// the HTTP response cache or the contents read from a reader.
+func (l *Loader) sources(filenames []string) ([]Source, error) {
+	filenames = l.filenames(filenames)
+	sources := make([]Source, len(filenames))
+	if l.fsys != nil {
+		for i, filename := range filenames {
+			sources[i] = FSSource(l.fsys, filename)
+		}
+
+		return sources, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, filename := range filenames {
+		if source, ok := l.parsed[filename]; ok {
+			sources[i] = source
+			continue
+		}
+
+		source, err := ParseSource(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := source.(*HTTPSource); ok && l.Timeout != 0 {
+			s.Timeout = l.Timeout
+		}
+
+		if l.parsed == nil {
+			l.parsed = make(map[string]Source)
+		}
+		l.parsed[filename] = source
+		sources[i] = source
+	}
+
+	return sources, nil
+}
+
+// keeps tells whether the key is listed in Keep.
+func (l *Loader) keeps(key string) bool {
+	for _, k := range l.Keep {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -106,6 +107,39 @@ func expandVariables(v string, m map[string]string) string {
 	})
 }
 
+// references returns the names of the variables whose references expandVariables replaces in v.
+func references(v string) []string {
+	var names []string
+	for _, submatch := range expandVarRegex.FindAllStringSubmatch(v, -1) {
+		if submatch[1] != "\\" && submatch[4] != "" {
+			names = append(names, submatch[4])
+		}
+	}
+
+	return names
+}
+
+// literalValue returns the value read from the raw value of a declaration when it has no references,
+// and false otherwise.
+func literalValue(raw string) (string, bool) {
+	quote, quoted := hasQuotePrefix([]byte(raw))
+	if quoted {
+		// trim quotes as extractVarValue does
+		raw = strings.Trim(raw[:len(raw)-1], string(quote))
+		if quote == prefixSingleQuote {
+			return raw, true
+		}
+
+		raw = expandEscapes(raw)
+	}
+
+	if len(references(raw)) > 0 {
+		return "", false
+	}
+
+	return expandVariables(raw, nil), true
+}
+
 func expandEscapes(str string) string {
 	out := escapeRegex.ReplaceAllStringFunc(str, func(match string) string {
 		c := strings.TrimPrefix(match, `\`)
@@ -122,6 +156,12 @@ func expandEscapes(str string) string {
 	return unescapeCharsRegex.ReplaceAllString(out, "$1")
 }
 
+// isKeyEnd tells whether the rune ends the key in an error message:
+// a separator or a space, after which the value may start.
+func isKeyEnd(r rune) bool {
+	return r == '=' || r == ':' || unicode.IsSpace(r)
+}
+
 // locateKeyName finds and parses the key name and returns the rest of the fragment.
 func locateKeyName(src []byte) (key string, cutset []byte, err error) {
 	// trim "export" and space at beginning
@@ -155,9 +195,15 @@ loop:
 				continue
 			}
 
+			// quote only the key, the value and the rest of the source may contain secrets
+			near := src
+			if end := bytes.IndexFunc(near, isKeyEnd); end != -1 {
+				near = near[:end]
+			}
+
 			return "", nil, fmt.Errorf(
 				`unexpected character %q in variable name near %q`,
-				string(char), string(src))
+				string(char), string(near))
 		}
 	}
 
@@ -172,7 +218,8 @@ loop:
 }
 
 // extractVarValue extracts a variable value and returns the rest of the fragment.
-func extractVarValue(src []byte, vars map[string]string) (value string, rest []byte, err error) {
+// If redact is true, errors do not include the source of the value.
+func extractVarValue(src []byte, vars map[string]string, redact bool) (value string, rest []byte, err error) {
 	quote, hasPrefix := hasQuotePrefix(src)
 	if !hasPrefix {
 		// unquoted value - read to the end of the line
@@ -186,8 +233,7 @@ func extractVarValue(src []byte, vars map[string]string) (value string, rest []b
 			}
 		}
 
-		// Converting a line into a rune away to perform an accurate rune countdown
-		line := []rune(string(src[0:endOfLine]))
+		line := src[0:endOfLine]
 
 		// Assume that the end of the string is the end of var
 		endOfVar := len(line)
@@ -195,19 +241,24 @@ func extractVarValue(src []byte, vars map[string]string) (value string, rest []b
 			return "", src[endOfLine:], nil
 		}
 
-		// Works backwards to check if the string ends with spaces, then a comment
-		for i := endOfVar - 1; i >= 0; i-- {
-			if line[i] == charComment && i > 0 {
-				if isSpace(line[i-1]) {
-					endOfVar = i
-					break
-				}
+		// Works backwards to check if the string ends with spaces, then a comment.
+		// Offsets are in bytes, the character before the comment is decoded
+		// so that a line with invalid UTF-8 keeps its length.
+		for i := endOfVar - 1; i > 0; i-- {
+			if line[i] != charComment {
+				continue
+			}
+
+			if prev, _ := utf8.DecodeLastRune(line[:i]); isSpace(prev) {
+				endOfVar = i
+				break
 			}
 		}
 
 		trimmed := strings.TrimFunc(string(line[0:endOfVar]), isSpace)
 
-		return expandVariables(trimmed, vars), src[endOfLine:], nil
+		// the rest starts at the inline comment, if any
+		return expandVariables(trimmed, vars), src[endOfVar:], nil
 	}
 
 	// lookup quoted string terminator
@@ -239,12 +290,40 @@ func extractVarValue(src []byte, vars map[string]string) (value string, rest []b
 		valEndIndex = len(src)
 	}
 
+	if redact {
+		return "", nil, fmt.Errorf("unterminated quoted value %s", RedactedValue)
+	}
+
 	return "", nil, fmt.Errorf("unterminated quoted value %s", src[:valEndIndex])
 }
 
 func parseBytes(src []byte, out map[string]string) error {
+	return parseBytesFunc(src, out, nil)
+}
+
+// declaration is a single key and value definition found by the parser.
+type declaration struct {
+	key   string
+	value string
+	// line is the 1-based line number of the key
+	line int
+	// sensitive is true if the key is annotated with sensitiveAnnotation
+	sensitive bool
+	// refs are the names of the variables referenced by the value
+	refs []string
+	// start, valueStart and valueEnd are the offsets in the source (with CRLF line endings replaced by LF)
+	// of the statement, of its value and of the end of the value, which includes trailing spaces
+	// if the value is unquoted and followed by a comment
+	start, valueStart, valueEnd int
+}
+
+// parseBytesFunc parses src into out like parseBytes,
+// calling visit (if not nil) for every declaration.
+// Changes made by visit to the value of the declaration are stored in out.
+func parseBytesFunc(src []byte, out map[string]string, visit func(d *declaration) error) error {
 	src = bytes.Replace(src, []byte("\r\n"), []byte("\n"), -1)
 	cutset := src
+	line, offset := 1, 0
 	for {
 		cutset = getStatementStart(cutset)
 		if cutset == nil {
@@ -252,19 +331,58 @@ func parseBytes(src []byte, out map[string]string) error {
 			break
 		}
 
+		// count the lines between the previous statement and this one
+		start := len(src) - len(cutset)
+		skipped := src[offset:start]
+		line += bytes.Count(skipped, []byte("\n"))
+
+		d := declaration{line: line, sensitive: isAnnotatedSensitive(skipped, offset == 0), start: start}
+		offset = start
+
 		key, left, err := locateKeyName(cutset)
 		if err != nil {
-			return err
+			return fmt.Errorf("line %d: %w", line, err)
 		}
 
-		value, left, err := extractVarValue(left, out)
+		d.key = key
+		d.sensitive = d.sensitive || IsSensitive(key)
+		d.valueStart = len(src) - len(left)
+		d.value, left, err = extractVarValue(left, out, d.sensitive)
 		if err != nil {
-			return err
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		d.valueEnd = len(src) - len(left)
+		if quote, _ := hasQuotePrefix(src[d.valueStart:]); quote != prefixSingleQuote {
+			d.refs = references(string(src[d.valueStart:d.valueEnd]))
+		}
+
+		if visit != nil {
+			if err = visit(&d); err != nil {
+				return err
+			}
 		}
 
-		out[key] = value
+		out[key] = d.value
 		cutset = left
 	}
 
 	return nil
 }
+
+// isAnnotatedSensitive tells whether the last comment line skipped before a statement is sensitiveAnnotation.
+// Unless the skipped text is at the start of the file,
+// its first line is the tail of the previous statement and is ignored.
+func isAnnotatedSensitive(skipped []byte, startOfFile bool) bool {
+	lines := bytes.Split(skipped, []byte("\n"))
+	if !startOfFile {
+		lines = lines[1:]
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		if l := bytes.TrimSpace(lines[i]); len(l) > 0 {
+			return string(l) == sensitiveAnnotation
+		}
+	}
+
+	return false
+}
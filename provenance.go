@@ -0,0 +1,107 @@
+package env
+
+// Status describes what happened to a single definition of a key.
+type Status int
+
+const (
+	// StatusSet means the definition was applied to a key that was not set before.
+	StatusSet Status = iota
+	// StatusOverridden means the definition was applied
+	// and replaced a value that was already set.
+	StatusOverridden
+	// StatusSkipped means the definition was ignored
+	// because the key already existed in the environment.
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusSet:
+		return "set"
+	case StatusOverridden:
+		return "overridden"
+	case StatusSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// Origin records where a definition of a key came from.
+type Origin struct {
+	File   string
+	Line   int
+	Status Status
+	// Sensitive is true if the key is annotated as sensitive in the file,
+	// matches SensitivePatterns or its value was decrypted or resolved from a secret reference.
+	Sensitive bool
+}
+
+func newOrigin(filename string, d declaration, status Status) Origin {
+	return Origin{File: filename, Line: d.line, Status: status, Sensitive: d.sensitive}
+}
+
+// Provenance maps every key found in the env files
+// to its definitions, in the order in which they were processed.
+type Provenance map[string][]Origin
+
+// Effective returns the definition whose value is in effect for the key.
+// It returns false if the key was not defined in any file or
+// if all its definitions were skipped, i.e. the value comes from the environment.
+func (p Provenance) Effective(key string) (Origin, bool) {
+	origins := p[key]
+	for i := len(origins) - 1; i >= 0; i-- {
+		if origins[i].Status != StatusSkipped {
+			return origins[i], true
+		}
+	}
+
+	return Origin{}, false
+}
+
+// Sensitive tells whether the value of the key is a secret,
+// either because it matches SensitivePatterns, because a file annotates it as sensitive
+// or because one of its values was encrypted or a secret reference.
+func (p Provenance) Sensitive(key string) bool {
+	for _, origin := range p[key] {
+		if origin.Sensitive {
+			return true
+		}
+	}
+
+	return IsSensitive(key)
+}
+
+// Redact returns RedactedValue if the key is sensitive and the value otherwise.
+func (p Provenance) Redact(key, value string) string {
+	if p.Sensitive(key) {
+		return RedactedValue
+	}
+
+	return value
+}
+
+func (p Provenance) add(key string, origin Origin) {
+	p[key] = append(p[key], origin)
+}
+
+// ReadWithProvenance works like Read,
+// but also reports the file and line that defined each key.
+// Definitions in later files override those in earlier ones.
+func ReadWithProvenance(filenames ...string) (map[string]string, Provenance, error) {
+	return NewLoader().ReadWithProvenance(filenames...)
+}
+
+// LoadWithProvenance works like Load,
+// but also reports the file and line that defined each key
+// and whether the definition was skipped because the key already existed in the environment.
+func LoadWithProvenance(filenames ...string) (Provenance, error) {
+	return NewLoader().LoadWithProvenance(filenames...)
+}
+
+// OverloadWithProvenance works like Overload,
+// but also reports the file and line that defined each key
+// and whether the definition overrode an existing environment variable.
+func OverloadWithProvenance(filenames ...string) (Provenance, error) {
+	return NewLoader().OverloadWithProvenance(filenames...)
+}
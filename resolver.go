@@ -0,0 +1,54 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretResolver resolves references to secrets kept outside of env files,
+// such as file:///run/secrets/db or secret://vault/path#field.
+// Resolvers are registered on a Loader for the scheme of the references they handle.
+type SecretResolver interface {
+	Resolve(ref *url.URL) (string, error)
+}
+
+// SecretResolverFunc is an adapter to allow the use of ordinary functions as secret resolvers.
+type SecretResolverFunc func(ref *url.URL) (string, error)
+
+// Resolve calls f(ref).
+func (f SecretResolverFunc) Resolve(ref *url.URL) (string, error) {
+	return f(ref)
+}
+
+// FileResolver resolves file:// references to the contents of the file,
+// as used by Docker and Kubernetes secret mounts.
+// A single trailing line break is removed.
+// References without a leading slash (file://secrets/db) are relative to the working directory.
+type FileResolver struct{}
+
+// Resolve reads the referenced file.
+func (FileResolver) Resolve(ref *url.URL) (string, error) {
+	content, err := os.ReadFile(filepath.FromSlash(ref.Host + ref.Path))
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSuffix(string(content), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// EnvResolver resolves env://NAME references to the value of the NAME environment variable.
+type EnvResolver struct{}
+
+// Resolve looks up the referenced variable.
+func (EnvResolver) Resolve(ref *url.URL) (string, error) {
+	value, ok := os.LookupEnv(ref.Host)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Host)
+	}
+
+	return value, nil
+}
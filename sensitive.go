@@ -0,0 +1,37 @@
+package env
+
+import "path"
+
+const (
+	// RedactedValue replaces the values of sensitive keys in errors and output.
+	RedactedValue = "[REDACTED]"
+
+	// sensitiveAnnotation is the comment marking the key on the next line as sensitive.
+	sensitiveAnnotation = "# @sensitive"
+)
+
+// SensitivePatterns are the patterns (in the syntax of path.Match)
+// of keys whose values are secrets.
+// Keys can also be marked as sensitive in env files
+// by putting a "# @sensitive" comment on the line above them.
+var SensitivePatterns = []string{"*_SECRET", "*_PASSWORD", "*_TOKEN"}
+
+// IsSensitive tells whether the key matches one of SensitivePatterns.
+func IsSensitive(key string) bool {
+	for _, pattern := range SensitivePatterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns RedactedValue if the key is sensitive and the value otherwise.
+func Redact(key, value string) string {
+	if IsSensitive(key) {
+		return RedactedValue
+	}
+
+	return value
+}
@@ -0,0 +1,271 @@
+package env
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout of the requests made by an HTTPSource without Timeout.
+const DefaultTimeout = 30 * time.Second
+
+// checksumFragment prefixes the checksum in the fragment of URLs parsed by ParseSource.
+const checksumFragment = "sha256="
+
+// Source provides the contents of an env file.
+type Source interface {
+	// Name identifies the source in provenance and errors, e.g. the name of the file.
+	Name() string
+	// Fetch returns the contents of the source.
+	Fetch() ([]byte, error)
+}
+
+// ParseSource returns the source named by a path or URI:
+//
+//   - "-" is the standard input
+//   - http:// and https:// URLs are fetched with an HTTPSource,
+//     whose contents must match the checksum given in a #sha256=HEX fragment, if any
+//   - file:// URLs and other names are files; named pipes and devices such as /dev/fd/N
+//     are only read once, like a ReaderSource
+func ParseSource(name string) (Source, error) {
+	switch {
+	case name == "-":
+		return StdinSource(), nil
+	case strings.HasPrefix(name, "http://"), strings.HasPrefix(name, "https://"):
+		u, err := url.Parse(name)
+		if err != nil {
+			return nil, err
+		}
+
+		source := &HTTPSource{}
+		if u.Fragment != "" {
+			checksum, ok := strings.CutPrefix(u.Fragment, checksumFragment)
+			if !ok {
+				return nil, fmt.Errorf("unsupported fragment in %s, expected #%sHEX", u.Redacted(), checksumFragment)
+			}
+
+			source.SHA256 = checksum
+			u.Fragment = ""
+		}
+		source.URL = u.String()
+		return source, nil
+	case strings.HasPrefix(name, "file://"):
+		u, err := url.Parse(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return FileSource(u.Host + u.Path), nil
+	default:
+		// pipes such as /dev/fd/N from process substitution can only be read once
+		if info, err := os.Stat(name); err == nil && info.Mode()&(fs.ModeNamedPipe|fs.ModeCharDevice) != 0 {
+			return pipeSource(name), nil
+		}
+
+		return FileSource(name), nil
+	}
+}
+
+type fileSource string
+
+// FileSource returns a source reading the file.
+func FileSource(filename string) Source {
+	return fileSource(filename)
+}
+
+func (s fileSource) Name() string {
+	return string(s)
+}
+
+func (s fileSource) Fetch() ([]byte, error) {
+	return os.ReadFile(string(s))
+}
+
+type fsSource struct {
+	fsys     fs.FS
+	filename string
+}
+
+// FSSource returns a source reading the file from fsys.
+func FSSource(fsys fs.FS, filename string) Source {
+	return fsSource{fsys: fsys, filename: filename}
+}
+
+func (s fsSource) Name() string {
+	return s.filename
+}
+
+func (s fsSource) Fetch() ([]byte, error) {
+	return fs.ReadFile(s.fsys, s.filename)
+}
+
+type stringSource struct {
+	name    string
+	content string
+}
+
+// StringSource returns a source with the content given inline.
+func StringSource(name, content string) Source {
+	return stringSource{name: name, content: content}
+}
+
+func (s stringSource) Name() string {
+	return s.name
+}
+
+func (s stringSource) Fetch() ([]byte, error) {
+	return []byte(s.content), nil
+}
+
+type readerSource struct {
+	name string
+	open func() (io.ReadCloser, error)
+
+	once    sync.Once
+	content []byte
+	err     error
+}
+
+// ReaderSource returns a source reading r the first time it is fetched.
+// As a reader such as a pipe cannot be read twice, later fetches return the same contents.
+func ReaderSource(name string, r io.Reader) Source {
+	return &readerSource{name: name, open: func() (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}}
+}
+
+// pipeSource returns a source reading the named pipe or device the first time it is fetched.
+func pipeSource(filename string) Source {
+	return &readerSource{name: filename, open: func() (io.ReadCloser, error) {
+		return os.Open(filename)
+	}}
+}
+
+// StdinSource returns a source reading the standard input, named "-".
+func StdinSource() Source {
+	return ReaderSource("-", os.Stdin)
+}
+
+func (s *readerSource) Name() string {
+	return s.name
+}
+
+func (s *readerSource) Fetch() ([]byte, error) {
+	s.once.Do(func() {
+		var r io.ReadCloser
+		if r, s.err = s.open(); s.err != nil {
+			return
+		}
+		defer r.Close()
+
+		s.content, s.err = io.ReadAll(r)
+	})
+
+	return s.content, s.err
+}
+
+// HTTPSource fetches an env file with a GET request.
+// The response is cached with its ETag, so that fetching an unmodified file again
+// only costs a request answered with 304 Not Modified.
+type HTTPSource struct {
+	URL string
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Timeout is the timeout of each request, DefaultTimeout if zero.
+	Timeout time.Duration
+	// SHA256 is the hex encoded checksum the contents must match, if not empty.
+	SHA256 string
+
+	mu      sync.Mutex
+	etag    string
+	content []byte
+}
+
+// Name returns the URL without credentials.
+func (s *HTTPSource) Name() string {
+	if u, err := url.Parse(s.URL); err == nil {
+		return u.Redacted()
+	}
+
+	return s.URL
+}
+
+// Fetch returns the body of the response, or the cached body if the file was not modified.
+func (s *HTTPSource) Fetch() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && s.etag != "":
+		return s.content, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cannot fetch %s: %s", s.Name(), resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.SHA256 != "" {
+		sum := sha256.Sum256(content)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), s.SHA256) {
+			return nil, fmt.Errorf("checksum mismatch for %s", s.Name())
+		}
+	}
+
+	s.etag, s.content = resp.Header.Get("ETag"), content
+	return content, nil
+}
+
+// ReadSources works like Read, reading the sources.
+func ReadSources(sources ...Source) (map[string]string, error) {
+	return NewLoader().ReadSources(sources...)
+}
+
+// LoadSources works like Load, reading the sources.
+func LoadSources(sources ...Source) error {
+	return NewLoader().LoadSources(sources...)
+}
+
+// OverloadSources works like Overload, reading the sources.
+func OverloadSources(sources ...Source) error {
+	return NewLoader().OverloadSources(sources...)
+}
@@ -0,0 +1,110 @@
+package env
+
+import (
+	"os"
+	"sort"
+	"sync"
+)
+
+// Env is a snapshot of the variables read from env files, safe for concurrent use.
+// Unlike Load, it leaves the environment of the current process untouched until Apply is called,
+// so that it can be read and reloaded while other goroutines use it.
+type Env struct {
+	loader    *Loader
+	filenames []string
+
+	mu   sync.RWMutex
+	vars map[string]string
+}
+
+// ReadEnv reads the files (.env by default) like Read into a new Env.
+func ReadEnv(filenames ...string) (*Env, error) {
+	return NewLoader().ReadEnv(filenames...)
+}
+
+// ReadEnv works like the package-level ReadEnv, reading the files with l.
+func (l *Loader) ReadEnv(filenames ...string) (*Env, error) {
+	filenames = l.filenames(filenames)
+	vars, err := l.Read(filenames...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Env{loader: l, filenames: filenames, vars: vars}, nil
+}
+
+// Get returns the value of the key, empty if it is not set.
+func (e *Env) Get(key string) string {
+	value, _ := e.Lookup(key)
+	return value
+}
+
+// Lookup returns the value of the key and whether it is set.
+func (e *Env) Lookup(key string) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	value, ok := e.vars[key]
+	return value, ok
+}
+
+// Keys returns the sorted keys.
+func (e *Env) Keys() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	keys := make([]string, 0, len(e.vars))
+	for key := range e.vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Map returns a copy of the variables.
+func (e *Env) Map() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	vars := make(map[string]string, len(e.vars))
+	for key, value := range e.vars {
+		vars[key] = value
+	}
+
+	return vars
+}
+
+// Reload reads the files again and replaces all the variables at once,
+// returning the keys that changed.
+// If the files cannot be read, the variables are left unchanged.
+func (e *Env) Reload() ([]Change, error) {
+	vars, err := e.loader.Read(e.filenames...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changes := Diff(e.vars, vars)
+	e.vars = vars
+	return changes, nil
+}
+
+// Apply sets the variables in the environment of the current process,
+// following the same precedence as Load, or Overload if overload is true.
+// The returned record can restore the previous environment.
+func (e *Env) Apply(overload bool) (*Applied, error) {
+	applied := &Applied{}
+	for key, value := range e.Map() {
+		if _, ok := os.LookupEnv(key); ok && !overload {
+			continue
+		}
+
+		if err := applied.setenv(key, value); err != nil {
+			return applied, err
+		}
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,225 @@
+package env
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval used by Watch when none is given.
+const DefaultWatchInterval = time.Second
+
+// Event is delivered by a Watcher when the values read from the watched files change.
+type Event struct {
+	// Changes lists the keys that changed since the previous values, sorted by key.
+	Changes []Change
+	// Err is the error reading the files after they were modified.
+	// The values of the Watcher are then left unchanged and Changes is empty.
+	Err error
+}
+
+// Watcher polls env files and reports the changes of the values read from them.
+// Files named by URLs are polled with conditional requests, using their ETag.
+// On Linux, the directories of the files are also watched with inotify,
+// so that changes are reported without waiting for the next poll.
+// It does not modify the environment of the current process.
+type Watcher struct {
+	loader    *Loader
+	sources   []Source
+	interval  time.Duration
+	fn        func(Event)
+	notified  <-chan struct{}
+	stop      func()
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.RWMutex
+	env    map[string]string
+	states []sourceState
+}
+
+// sourceState is what a Watcher compares to detect that a source was modified:
+// the checksum of its contents, or the error fetching it.
+type sourceState struct {
+	sum [sha256.Size]byte
+	err string
+}
+
+// Watch reads the files (.env by default) like Read,
+// then polls them every interval (DefaultWatchInterval if not positive) until the Watcher is closed.
+// The changes are delivered on the channel returned by Events.
+func Watch(interval time.Duration, filenames ...string) (*Watcher, error) {
+	return NewLoader().Watch(interval, filenames...)
+}
+
+// WatchFunc works like Watch, but calls fn with every event instead of sending it on a channel.
+// The calls are made one at a time by the goroutine of the Watcher.
+func WatchFunc(interval time.Duration, fn func(Event), filenames ...string) (*Watcher, error) {
+	return NewLoader().WatchFunc(interval, fn, filenames...)
+}
+
+// Watch works like the package-level Watch, reading the files with l.
+func (l *Loader) Watch(interval time.Duration, filenames ...string) (*Watcher, error) {
+	return l.watch(interval, nil, filenames)
+}
+
+// WatchFunc works like the package-level WatchFunc, reading the files with l.
+func (l *Loader) WatchFunc(interval time.Duration, fn func(Event), filenames ...string) (*Watcher, error) {
+	return l.watch(interval, fn, filenames)
+}
+
+func (l *Loader) watch(interval time.Duration, fn func(Event), filenames []string) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	sources, err := l.sources(filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		loader:   l,
+		sources:  sources,
+		interval: interval,
+		fn:       fn,
+		events:   make(chan Event),
+		done:     make(chan struct{}),
+	}
+
+	// changes made while the files are read are notified
+	w.notified, w.stop = notify(sources)
+	states, snapshot, err := w.fetch()
+	if err == nil {
+		w.env, _, err = l.readSources(snapshot)
+	}
+	if err != nil {
+		w.stop()
+		return nil, err
+	}
+	w.states = states
+
+	go w.run()
+	return w, nil
+}
+
+// Events returns the channel the changes are delivered on.
+// Polling waits for each event to be received, and the channel is closed by Close.
+// No events are sent on it by a Watcher created with WatchFunc.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Env returns a copy of the last values successfully read from the files.
+func (w *Watcher) Env() map[string]string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	envMap := make(map[string]string, len(w.env))
+	for key, value := range w.env {
+		envMap[key] = value
+	}
+
+	return envMap
+}
+
+// Close stops polling the files.
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	defer w.stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		case <-w.notified:
+		}
+
+		event, ok := w.poll()
+		if !ok {
+			continue
+		}
+
+		if w.fn != nil {
+			w.fn(event)
+			continue
+		}
+
+		select {
+		case w.events <- event:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poll reads the sources again if any of them was modified,
+// returning false if there is nothing to report.
+func (w *Watcher) poll() (Event, bool) {
+	states, snapshot, err := w.fetch()
+	if equalStates(states, w.states) {
+		return Event{}, false
+	}
+	w.states = states
+
+	if err != nil {
+		return Event{Err: err}, true
+	}
+
+	envMap, _, err := w.loader.readSources(snapshot)
+	if err != nil {
+		return Event{Err: err}, true
+	}
+
+	w.mu.Lock()
+	changes := Diff(w.env, envMap)
+	w.env = envMap
+	w.mu.Unlock()
+
+	return Event{Changes: changes}, len(changes) > 0
+}
+
+// fetch returns the state of every source and sources holding the contents fetched,
+// so that the values are read from the contents whose checksums were taken.
+// The error is the first error fetching a source.
+func (w *Watcher) fetch() (states []sourceState, snapshot []Source, err error) {
+	states = make([]sourceState, len(w.sources))
+	snapshot = make([]Source, len(w.sources))
+	for i, source := range w.sources {
+		content, fetchErr := source.Fetch()
+		if fetchErr != nil {
+			states[i].err = fetchErr.Error()
+			if err == nil {
+				err = fetchErr
+			}
+			continue
+		}
+
+		states[i].sum = sha256.Sum256(content)
+		snapshot[i] = StringSource(source.Name(), string(content))
+	}
+
+	return
+}
+
+func equalStates(a, b []sourceState) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,83 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the events of a directory that modify a file in it once complete:
+// the file is closed after writing, renamed (as editors replace files) or deleted.
+// Writes in progress are not reported, so that a file is not read before it is fully written.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// notify watches the directories of the files among the sources with inotify,
+// returning a channel receiving a value when one of the files may have changed
+// and a function stopping the watch.
+// Sources that cannot be watched are only polled.
+func notify(sources []Source) (<-chan struct{}, func()) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, func() {}
+	}
+
+	dirs := make(map[int32]string)
+	files := make(map[string]bool)
+	for _, source := range sources {
+		filename, ok := source.(fileSource)
+		if !ok {
+			continue
+		}
+
+		path, err := filepath.Abs(string(filename))
+		if err != nil {
+			continue
+		}
+
+		wd, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), inotifyMask)
+		if err != nil {
+			continue
+		}
+
+		dirs[int32(wd)] = filepath.Dir(path)
+		files[path] = true
+	}
+
+	// the file is non-blocking, so that closing it stops the pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+	if len(files) == 0 {
+		file.Close()
+		return nil, func() {}
+	}
+
+	notified := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				name := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+				path := filepath.Join(dirs[event.Wd], string(bytes.TrimRight(name, "\x00")))
+				if !files[path] {
+					continue
+				}
+
+				select {
+				case notified <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return notified, func() { file.Close() }
+}
@@ -0,0 +1,41 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchNotify(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/.env"
+	if err := os.WriteFile(filename, []byte("A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the interval is too long for polling to report the change
+	w, err := Watch(time.Hour, filename)
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	defer w.Close()
+
+	// replace the file like an editor does
+	if err = os.WriteFile(dir+"/.env.tmp", []byte("A=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(dir+"/.env.tmp", filename); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{{Key: "A", Kind: Changed, Old: "1", New: "2"}}
+	select {
+	case event := <-w.Events():
+		if event.Err != nil || !reflect.DeepEqual(event.Changes, expected) {
+			t.Errorf("Expected %v, got %v (%v)", expected, event.Changes, event.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an event")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package env
+
+// notify returns no notifications where inotify is not available, the sources are only polled.
+func notify(sources []Source) (<-chan struct{}, func()) {
+	return nil, func() {}
+}